@@ -19,6 +19,8 @@ import ProductUpdate from './pages/ProductUpdate';
 import CategoryUpdate from './pages/CategoryUpdate';
 import ProductNew from './pages/ProductNew';
 import CategoryNew from './pages/CategoryNew';
+import MFAEnroll from './pages/MFAEnroll';
+import AcceptInvitation from './pages/AcceptInvitation';
 
 function App() {
   return (
@@ -35,6 +37,8 @@ function App() {
                 <Route path="/verify/:token" element={<VerifyEmail />} />
                 <Route path="/reset-password" element={<ResetPassword />} />
                 <Route path="/reset-password/:token" element={<ResetPassword />} />
+                <Route path="/mfa/enroll" element={<MFAEnroll />} />
+                <Route path="/invitations/accept/:token" element={<AcceptInvitation />} />
                 <Route path="/products" element={<Products />} />
                 <Route path="/products/:id" element={<ProductDetails />} />
                 <Route path="/products/edit/:id" element={<ProductUpdate />} />
@@ -27,7 +27,8 @@ api.interceptors.request.use(
 api.interceptors.response.use(
   (response) => response,
   (error) => {
-    if (error.response?.status === 401) {
+    // Failed logins and incorrect two-factor codes are reported by the login pages
+    if (error.response?.status === 401 && !error.config?.url?.startsWith('/users/login')) {
       localStorage.removeItem('token');
       window.location.href = '/login';
     }
@@ -59,6 +60,8 @@ export const auth = {
     api.post('/users/register', { email, password }),
   login: (email: string, password: string) =>
     api.post('/users/login', { email, password }),
+  verifyMFA: (mfaToken: string, code: string) =>
+    api.post('/users/login/mfa', { mfa_token: mfaToken, code }),
   verifyEmail: (token: string) => api.get(`/users/verify/${token}`),
   requestPasswordReset: (email: string) =>
     api.post('/users/password/reset', { email }),
@@ -66,6 +69,16 @@ export const auth = {
     api.post(`/users/password/reset/${token}`, { new_password: newPassword }),
 };
 
+export const invitations = {
+  accept: (token: string, password: string, name: string) =>
+    api.post('/invitations/accept', { token, password, name }),
+};
+
+export const mfa = {
+  beginEnrollment: () => api.post('/users/me/mfa/enroll'),
+  confirmEnrollment: (code: string) => api.post('/users/me/mfa/confirm', { code }),
+};
+
 export const products = {
   getAll: (params: {
     name?: string;
@@ -102,7 +115,17 @@ export const categories = {
 };
 
 export const users = {
-  getAll: () => api.get('/users', { headers: { Authorization: `Bearer ${localStorage.getItem('token')}` } }),
+  getAll: (params: {
+    email?: string;
+    role?: string;
+    verified?: boolean;
+    created_from?: string;
+    created_to?: string;
+    sort?: string;
+    order?: 'asc' | 'desc';
+    page?: number;
+    limit?: number;
+  } = {}) => api.get('/users', { params, headers: { Authorization: `Bearer ${localStorage.getItem('token')}` } }),
   getOne: (id: string) => api.get(`/users/${id}`, { headers: { Authorization: `Bearer ${localStorage.getItem('token')}` } }),
   update: (id: string, data: any) => api.put(`/users/${id}`, data, { headers: { Authorization: `Bearer ${localStorage.getItem('token')}` } }),
   delete: (id: string) => api.delete(`/users/${id}`, { headers: { Authorization: `Bearer ${localStorage.getItem('token')}` } }),
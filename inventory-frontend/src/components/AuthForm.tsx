@@ -16,6 +16,7 @@ import {
 } from '@/components/ui/form';
 import { Input } from '@/components/ui/input';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
+import { errorMessage } from '@/lib/utils';
 
 const formSchema = z.object({
   email: z.string().email('Invalid email address'),
@@ -45,7 +46,7 @@ export function AuthForm({ mode, onSubmit, title }: AuthFormProps) {
       setLoading(true);
       await onSubmit(data);
     } catch (error: any) {
-      toast.error(error.response?.data || 'An error occurred');
+      toast.error(errorMessage(error, 'An error occurred'));
     } finally {
       setLoading(false);
     }
@@ -4,3 +4,12 @@ import { twMerge } from 'tailwind-merge';
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs));
 }
+
+// errorMessage returns the detail of a problem response from the API, or fallback.
+export function errorMessage(error: any, fallback: string): string {
+  const data = error?.response?.data;
+  if (typeof data === 'string' && data) {
+    return data;
+  }
+  return data?.detail || fallback;
+}
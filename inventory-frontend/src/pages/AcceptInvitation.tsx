@@ -0,0 +1,101 @@
+import { useState } from 'react';
+import { useParams, useNavigate } from 'react-router-dom';
+import { invitations } from '@/api/api';
+import { toast } from 'sonner';
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import { Loader2 } from 'lucide-react';
+import { errorMessage } from '@/lib/utils';
+
+export default function AcceptInvitation() {
+  const { token } = useParams<{ token: string }>();
+  const navigate = useNavigate();
+  const [name, setName] = useState('');
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const passwordError =
+    password && password.length < 8
+      ? 'Password must be at least 8 characters'
+      : confirmPassword && password !== confirmPassword
+        ? 'Passwords do not match'
+        : '';
+
+  const handleAccept = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!token || passwordError) return;
+    try {
+      setLoading(true);
+      await invitations.accept(token, password, name.trim());
+      toast.success('Invitation accepted, you can now log in');
+      navigate('/login');
+    } catch (error) {
+      toast.error(errorMessage(error, 'Failed to accept the invitation'));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="container max-w-lg mx-auto px-4">
+      <Card className="mt-8 bg-background border-border">
+        <CardHeader>
+          <CardTitle className="text-2xl font-bold text-center text-foreground">
+            Accept Invitation
+          </CardTitle>
+        </CardHeader>
+        <CardContent>
+          <form onSubmit={handleAccept} className="space-y-4">
+            <p className="text-center text-muted-foreground">
+              Choose a password to finish setting up your account.
+            </p>
+            <div className="space-y-2">
+              <Label htmlFor="name" className="text-foreground">Name (optional)</Label>
+              <Input
+                id="name"
+                value={name}
+                maxLength={100}
+                onChange={(e) => setName(e.target.value)}
+                className="bg-background text-foreground border-border"
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="password" className="text-foreground">Password</Label>
+              <Input
+                id="password"
+                type="password"
+                autoComplete="new-password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                className="bg-background text-foreground border-border"
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="confirmPassword" className="text-foreground">Confirm Password</Label>
+              <Input
+                id="confirmPassword"
+                type="password"
+                autoComplete="new-password"
+                value={confirmPassword}
+                onChange={(e) => setConfirmPassword(e.target.value)}
+                className="bg-background text-foreground border-border"
+              />
+              {passwordError && <p className="text-sm text-red-500">{passwordError}</p>}
+            </div>
+            <Button
+              type="submit"
+              className="w-full bg-blue-500 hover:bg-blue-600 text-white"
+              disabled={loading || !password || password !== confirmPassword || !!passwordError}
+            >
+              {loading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+              Create Account
+            </Button>
+          </form>
+        </CardContent>
+      </Card>
+    </div>
+  );
+}
@@ -1,20 +1,96 @@
+import { useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { AuthForm } from '@/components/AuthForm';
 import { auth } from '@/api/api';
 import { useAuth } from '@/context/AuthContext';
 import { toast } from 'sonner';
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Loader2 } from 'lucide-react';
+import { errorMessage } from '@/lib/utils';
 
 export default function Login() {
   const navigate = useNavigate();
   const { login } = useAuth();
+  // Set when the password was right but a second factor is needed
+  const [mfaToken, setMfaToken] = useState('');
+  const [code, setCode] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const completeLogin = (token: string, enrollmentRequired: boolean) => {
+    login(token);
+    toast.success('Successfully logged in');
+    // Users whose role requires two-factor authentication must set it up first
+    navigate(enrollmentRequired ? '/mfa/enroll' : '/products');
+  };
 
   const handleLogin = async ({ email, password }: { email: string; password: string }) => {
     const response = await auth.login(email, password);
-    login(response.data.token);
-    toast.success('Successfully logged in');
-    navigate('/products');
+    if (response.data.mfa_required) {
+      setMfaToken(response.data.mfa_token);
+      return;
+    }
+    completeLogin(response.data.token, !!response.data.mfa_enrollment_required);
   };
 
+  const handleVerify = async () => {
+    try {
+      setLoading(true);
+      const response = await auth.verifyMFA(mfaToken, code.trim());
+      completeLogin(response.data.token, false);
+    } catch (error: any) {
+      toast.error(errorMessage(error, 'Failed to verify the code'));
+      setCode('');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  if (mfaToken) {
+    return (
+      <div className="container max-w-lg mx-auto px-4">
+        <Card className="mt-8 bg-background border-border">
+          <CardHeader>
+            <CardTitle className="text-2xl font-bold text-center text-foreground">
+              Two-Factor Authentication
+            </CardTitle>
+          </CardHeader>
+          <CardContent className="space-y-4">
+            <p className="text-center text-muted-foreground">
+              Enter the code from your authenticator app, or one of your recovery codes.
+            </p>
+            <div className="space-y-2">
+              <Input
+                placeholder="123456"
+                autoComplete="one-time-code"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                onKeyDown={(e) => e.key === 'Enter' && code.trim() && handleVerify()}
+                className="bg-background text-foreground border-border"
+              />
+              <Button
+                className="w-full bg-blue-500 hover:bg-blue-600 text-white"
+                onClick={handleVerify}
+                disabled={loading || !code.trim()}
+              >
+                {loading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+                Verify
+              </Button>
+            </div>
+            <Button
+              variant="link"
+              className="w-full text-blue-500 hover:text-blue-600"
+              onClick={() => setMfaToken('')}
+            >
+              Back to Login
+            </Button>
+          </CardContent>
+        </Card>
+      </div>
+    );
+  }
+
   return (
     <div className="container max-w-lg mx-auto px-4">
       <AuthForm
@@ -24,4 +100,4 @@ export default function Login() {
       />
     </div>
   );
-}
\ No newline at end of file
+}
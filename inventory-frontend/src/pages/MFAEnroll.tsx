@@ -0,0 +1,137 @@
+import { useEffect, useRef, useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { mfa } from '@/api/api';
+import { useAuth } from '@/context/AuthContext';
+import { toast } from 'sonner';
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
+import { Button } from '@/components/ui/button';
+import { Input } from '@/components/ui/input';
+import { Loader2 } from 'lucide-react';
+import { errorMessage } from '@/lib/utils';
+
+interface Enrollment {
+  secret: string;
+  otpauth_uri: string;
+}
+
+export default function MFAEnroll() {
+  const navigate = useNavigate();
+  const { login } = useAuth();
+  const [enrollment, setEnrollment] = useState<Enrollment | null>(null);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const [code, setCode] = useState('');
+  const [loading, setLoading] = useState(false);
+  // Each call generates a new secret, so enrolment must begin only once
+  const started = useRef(false);
+
+  useEffect(() => {
+    if (started.current) return;
+    started.current = true;
+    if (!localStorage.getItem('token')) {
+      navigate('/login');
+      return;
+    }
+    mfa.beginEnrollment()
+      .then((response) => setEnrollment(response.data))
+      .catch((error) => {
+        toast.error(errorMessage(error, 'Failed to start two-factor enrolment'));
+        navigate('/products');
+      });
+  }, [navigate]);
+
+  const handleConfirm = async () => {
+    try {
+      setLoading(true);
+      const response = await mfa.confirmEnrollment(code.trim());
+      // The new session counts as having passed the second factor
+      login(response.data.token);
+      setRecoveryCodes(response.data.recovery_codes || []);
+      toast.success('Two-factor authentication enabled');
+    } catch (error) {
+      toast.error(errorMessage(error, 'Failed to confirm the code'));
+      setCode('');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  if (recoveryCodes.length > 0) {
+    return (
+      <div className="container max-w-lg mx-auto px-4">
+        <Card className="mt-8 bg-background border-border">
+          <CardHeader>
+            <CardTitle className="text-2xl font-bold text-center text-foreground">
+              Save Your Recovery Codes
+            </CardTitle>
+          </CardHeader>
+          <CardContent className="space-y-4">
+            <p className="text-center text-muted-foreground">
+              Each code can be used once to sign in if you lose your authenticator. They will not be shown again.
+            </p>
+            <ul className="grid grid-cols-2 gap-2 font-mono text-center text-foreground">
+              {recoveryCodes.map((recoveryCode) => (
+                <li key={recoveryCode}>{recoveryCode}</li>
+              ))}
+            </ul>
+            <Button
+              className="w-full bg-blue-500 hover:bg-blue-600 text-white"
+              onClick={() => navigate('/products')}
+            >
+              I have saved my codes
+            </Button>
+          </CardContent>
+        </Card>
+      </div>
+    );
+  }
+
+  return (
+    <div className="container max-w-lg mx-auto px-4">
+      <Card className="mt-8 bg-background border-border">
+        <CardHeader>
+          <CardTitle className="text-2xl font-bold text-center text-foreground">
+            Set Up Two-Factor Authentication
+          </CardTitle>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          {!enrollment ? (
+            <div className="flex justify-center">
+              <Loader2 className="h-6 w-6 animate-spin text-muted-foreground" />
+            </div>
+          ) : (
+            <>
+              <p className="text-center text-muted-foreground">
+                Add this account to your authenticator app, by opening the link on your phone or entering the key by hand, then enter the code it shows.
+              </p>
+              <a
+                href={enrollment.otpauth_uri}
+                className="block text-center text-blue-500 hover:text-blue-600 break-all"
+              >
+                Open in authenticator app
+              </a>
+              <p className="text-center font-mono text-foreground break-all">{enrollment.secret}</p>
+              <div className="space-y-2">
+                <Input
+                  placeholder="123456"
+                  autoComplete="one-time-code"
+                  value={code}
+                  onChange={(e) => setCode(e.target.value)}
+                  onKeyDown={(e) => e.key === 'Enter' && code.trim() && handleConfirm()}
+                  className="bg-background text-foreground border-border"
+                />
+                <Button
+                  className="w-full bg-blue-500 hover:bg-blue-600 text-white"
+                  onClick={handleConfirm}
+                  disabled={loading || !code.trim()}
+                >
+                  {loading && <Loader2 className="mr-2 h-4 w-4 animate-spin" />}
+                  Enable
+                </Button>
+              </div>
+            </>
+          )}
+        </CardContent>
+      </Card>
+    </div>
+  );
+}
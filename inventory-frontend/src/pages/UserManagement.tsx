@@ -39,47 +39,121 @@ import {
   SelectValue,
 } from '@/components/ui/select';
 import { Pencil, Trash2, Loader2, Eye } from 'lucide-react';
+import { errorMessage } from '@/lib/utils';
 
 interface User {
   id: string;
   email: string;
   role: string;
   is_verified: boolean;
+  created_at: string;
+  last_login_at?: string;
 }
 
+// Initial filter state
+const initialFilters = {
+  email: '',
+  role: 'all',
+  verified: 'all',
+  created_from: '',
+  created_to: '',
+};
+
 export default function UserManagement() {
   const { isAuthenticated, isAdmin } = useAuth();
   const [userList, setUserList] = useState<User[]>([]);
   const [selectedUser, setSelectedUser] = useState<User | null>(null);
   const [isDialogOpen, setIsDialogOpen] = useState(false);
   const [isLoading, setIsLoading] = useState(true);
+  const [totalPages, setTotalPages] = useState(1);
+  const [currentPage, setCurrentPage] = useState(1);
+  const [limit] = useState(10);
   const [formData, setFormData] = useState({
     email: '',
     role: '',
     is_verified: false,
   });
 
+  // Filter input state (temporary, not applied yet)
+  const [inputFilters, setInputFilters] = useState(initialFilters);
+
+  // Applied filters (used for API call)
+  const [appliedFilters, setAppliedFilters] = useState(initialFilters);
+
+  const [sort, setSort] = useState<{ field: string; order: 'asc' | 'desc' }>({
+    field: 'created_at',
+    order: 'desc',
+  });
+
+  const fetchUsers = async () => {
+    const response = await users.getAll({
+      email: appliedFilters.email || undefined,
+      role: appliedFilters.role === 'all' ? undefined : appliedFilters.role,
+      verified: appliedFilters.verified === 'all' ? undefined : appliedFilters.verified === 'true',
+      created_from: appliedFilters.created_from || undefined,
+      created_to: appliedFilters.created_to || undefined,
+      sort: sort.field,
+      order: sort.order,
+      page: currentPage,
+      limit,
+    });
+    setUserList(response.data.users || []);
+    setTotalPages(response.data.total_pages || 1);
+  };
+
   useEffect(() => {
-    const fetchUsers = async () => {
-      if (!isAdmin) return;
+    const loadUsers = async () => {
       try {
         setIsLoading(true);
-        const response = await users.getAll();
-        setUserList(response.data || []);
+        await fetchUsers();
       } catch (error) {
         toast.error('Failed to load users');
         setUserList([]);
+        setTotalPages(1);
       } finally {
         setIsLoading(false);
       }
     };
 
     if (isAuthenticated && isAdmin) {
-      fetchUsers();
+      loadUsers();
     } else {
       setIsLoading(false);
     }
-  }, [isAuthenticated, isAdmin]);
+  }, [isAuthenticated, isAdmin, currentPage, appliedFilters, sort]);
+
+  const handleFilterChange = (e: React.ChangeEvent<HTMLInputElement>) => {
+    const { name, value } = e.target;
+    setInputFilters((prev) => ({ ...prev, [name]: value }));
+  };
+
+  const handleApplyFilters = () => {
+    setAppliedFilters({ ...inputFilters });
+    setCurrentPage(1); // Reset to first page when applying filters
+  };
+
+  const handleResetFilters = () => {
+    setInputFilters(initialFilters);
+    setAppliedFilters(initialFilters);
+    setCurrentPage(1); // Reset to first page when resetting filters
+  };
+
+  const handleSortChange = (field: string) => {
+    setSort((prev) => ({
+      field,
+      order: prev.field === field && prev.order === 'asc' ? 'desc' : 'asc',
+    }));
+    setCurrentPage(1);
+  };
+
+  const handlePageChange = (page: number) => {
+    if (page >= 1 && page <= totalPages) {
+      setCurrentPage(page);
+    }
+  };
+
+  const sortIndicator = (field: string) =>
+    sort.field === field && (sort.order === 'asc' ? '↑' : '↓');
 
   const handleInputChange = (e: React.ChangeEvent<HTMLInputElement>) => {
     const { name, value } = e.target;
@@ -113,10 +187,9 @@ export default function UserManagement() {
       setIsDialogOpen(false);
       setSelectedUser(null);
       setFormData({ email: '', role: '', is_verified: false });
-      const response = await users.getAll();
-      setUserList(response.data || []);
+      await fetchUsers();
     } catch (error: any) {
-      toast.error(error.response?.data?.error || 'Failed to update user');
+      toast.error(errorMessage(error, 'Failed to update user'));
     }
   };
 
@@ -128,10 +201,14 @@ export default function UserManagement() {
     try {
       await users.delete(id);
       toast.success('User deleted successfully');
-      const response = await users.getAll();
-      setUserList(response.data || []);
+      // The last user of the last page leaves that page empty
+      if (userList.length === 1 && currentPage > 1) {
+        setCurrentPage(currentPage - 1);
+      } else {
+        await fetchUsers();
+      }
     } catch (error: any) {
-      toast.error(error.response?.data?.error || 'Failed to delete user');
+      toast.error(errorMessage(error, 'Failed to delete user'));
     }
   };
 
@@ -179,6 +256,83 @@ export default function UserManagement() {
         <h1 className="text-2xl font-bold text-foreground">User Management</h1>
       </div>
 
+      {/* Filters */}
+      <div className="mb-6 grid grid-cols-1 md:grid-cols-6 gap-4 items-end">
+        <div>
+          <Label htmlFor="emailFilter" className="text-foreground">Email</Label>
+          <Input
+            id="emailFilter"
+            name="email"
+            value={inputFilters.email}
+            onChange={handleFilterChange}
+            placeholder="Filter by email"
+            className="bg-background text-foreground border-border"
+          />
+        </div>
+        <div>
+          <Label htmlFor="roleFilter" className="text-foreground">Role</Label>
+          <Select
+            value={inputFilters.role}
+            onValueChange={(value) => setInputFilters((prev) => ({ ...prev, role: value }))}
+          >
+            <SelectTrigger id="roleFilter" className="bg-background text-foreground border-border">
+              <SelectValue placeholder="All Roles" />
+            </SelectTrigger>
+            <SelectContent className="bg-background border-border">
+              <SelectItem value="all" className="text-foreground">All Roles</SelectItem>
+              <SelectItem value="admin" className="text-foreground">Admin</SelectItem>
+              <SelectItem value="user" className="text-foreground">User</SelectItem>
+            </SelectContent>
+          </Select>
+        </div>
+        <div>
+          <Label htmlFor="verifiedFilter" className="text-foreground">Verified</Label>
+          <Select
+            value={inputFilters.verified}
+            onValueChange={(value) => setInputFilters((prev) => ({ ...prev, verified: value }))}
+          >
+            <SelectTrigger id="verifiedFilter" className="bg-background text-foreground border-border">
+              <SelectValue placeholder="All" />
+            </SelectTrigger>
+            <SelectContent className="bg-background border-border">
+              <SelectItem value="all" className="text-foreground">All</SelectItem>
+              <SelectItem value="true" className="text-foreground">Yes</SelectItem>
+              <SelectItem value="false" className="text-foreground">No</SelectItem>
+            </SelectContent>
+          </Select>
+        </div>
+        <div>
+          <Label htmlFor="created_from" className="text-foreground">Created From</Label>
+          <Input
+            id="created_from"
+            name="created_from"
+            type="date"
+            value={inputFilters.created_from}
+            onChange={handleFilterChange}
+            className="bg-background text-foreground border-border"
+          />
+        </div>
+        <div>
+          <Label htmlFor="created_to" className="text-foreground">Created To</Label>
+          <Input
+            id="created_to"
+            name="created_to"
+            type="date"
+            value={inputFilters.created_to}
+            onChange={handleFilterChange}
+            className="bg-background text-foreground border-border"
+          />
+        </div>
+        <div className="flex space-x-2">
+          <Button onClick={handleApplyFilters} className="bg-blue-500 hover:bg-blue-600 text-white">
+            Apply
+          </Button>
+          <Button onClick={handleResetFilters} variant="outline">
+            Reset
+          </Button>
+        </div>
+      </div>
+
       {userList.length === 0 ? (
         <div className="text-center py-8 text-muted-foreground">
           No users found.
@@ -188,9 +342,19 @@ export default function UserManagement() {
           <Table>
             <TableHeader>
               <TableRow className="bg-background">
-                <TableHead className="text-foreground">Email</TableHead>
-                <TableHead className="text-foreground">Role</TableHead>
+                <TableHead className="text-foreground cursor-pointer" onClick={() => handleSortChange('email')}>
+                  Email {sortIndicator('email')}
+                </TableHead>
+                <TableHead className="text-foreground cursor-pointer" onClick={() => handleSortChange('role')}>
+                  Role {sortIndicator('role')}
+                </TableHead>
                 <TableHead className="text-foreground">Verified</TableHead>
+                <TableHead className="text-foreground cursor-pointer" onClick={() => handleSortChange('created_at')}>
+                  Created {sortIndicator('created_at')}
+                </TableHead>
+                <TableHead className="text-foreground cursor-pointer" onClick={() => handleSortChange('last_login_at')}>
+                  Last Login {sortIndicator('last_login_at')}
+                </TableHead>
                 <TableHead className="text-foreground">Actions</TableHead>
               </TableRow>
             </TableHeader>
@@ -200,6 +364,10 @@ export default function UserManagement() {
                   <TableCell className="font-medium text-foreground">{user.email}</TableCell>
                   <TableCell className="text-foreground">{user.role}</TableCell>
                   <TableCell className="text-foreground">{user.is_verified ? 'Yes' : 'No'}</TableCell>
+                  <TableCell className="text-foreground">{new Date(user.created_at).toLocaleDateString()}</TableCell>
+                  <TableCell className="text-foreground">
+                    {user.last_login_at ? new Date(user.last_login_at).toLocaleString() : 'Never'}
+                  </TableCell>
                   <TableCell>
                     <div className="flex space-x-2">
                       <Button variant="outline" size="icon" asChild>
@@ -247,6 +415,29 @@ export default function UserManagement() {
         </div>
       )}
 
+      {/* Pagination */}
+      {totalPages > 1 && (
+        <div className="flex justify-between items-center mt-6">
+          <Button
+            onClick={() => handlePageChange(currentPage - 1)}
+            disabled={currentPage === 1}
+            variant="outline"
+          >
+            Previous
+          </Button>
+          <span className="text-foreground">
+            Page {currentPage} of {totalPages}
+          </span>
+          <Button
+            onClick={() => handlePageChange(currentPage + 1)}
+            disabled={currentPage === totalPages}
+            variant="outline"
+          >
+            Next
+          </Button>
+        </div>
+      )}
+
       <Dialog open={isDialogOpen} onOpenChange={setIsDialogOpen}>
         <DialogContent className="bg-background border-border">
           <DialogHeader>
@@ -0,0 +1,305 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/dto"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/services"
+	"inventory-service/utils"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const emailChangeTokenTTL = 24 * time.Hour
+
+var (
+	ErrUserNotFound      = domain.ErrUserNotFound
+	ErrIncorrectPassword = domain.NewForbiddenError("current password is incorrect")
+	ErrEmailTaken        = domain.NewConflictError("email address is already in use")
+)
+
+// AccountUsecase covers the operations users perform on their own account.
+type AccountUsecase struct {
+	repo           domain.UserRepository
+	attempts       domain.LoginAttemptRepository
+	auditRepo      domain.AuditRepository
+	invitationRepo domain.InvitationRepository
+	deliveryRepo   domain.EmailDeliveryRepository
+	outboxRepo     domain.OutboxRepository
+	tx             domain.Transactor
+	emailService   services.EmailService
+}
+
+func NewAccountUsecase(repo domain.UserRepository, attempts domain.LoginAttemptRepository, auditRepo domain.AuditRepository, invitationRepo domain.InvitationRepository, deliveryRepo domain.EmailDeliveryRepository, outboxRepo domain.OutboxRepository, tx domain.Transactor, emailService services.EmailService) *AccountUsecase {
+	return &AccountUsecase{
+		repo:           repo,
+		attempts:       attempts,
+		auditRepo:      auditRepo,
+		invitationRepo: invitationRepo,
+		deliveryRepo:   deliveryRepo,
+		outboxRepo:     outboxRepo,
+		tx:             tx,
+		emailService:   emailService,
+	}
+}
+
+func (uc *AccountUsecase) GetProfile(ctx context.Context, userID string) (*dto.UserDTO, error) {
+	user, err := uc.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return toUserDTO(user), nil
+}
+
+func (uc *AccountUsecase) UpdateProfile(ctx context.Context, userID string, req *dto.UpdateProfileRequest) (*dto.UserDTO, error) {
+	user, err := uc.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.Name = strings.TrimSpace(req.Name)
+	if req.Locale != "" {
+		user.Locale = req.Locale
+	}
+	if err := uc.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return toUserDTO(user), nil
+}
+
+// ChangePassword replaces the password after checking the current one. Every other
+// session is logged out; the returned token replaces the caller's.
+func (uc *AccountUsecase) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string, mfa bool) (string, error) {
+	user, err := uc.repo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+	if err := uc.checkPassword(ctx, user, currentPassword); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	user.Password = string(hashedPassword)
+	user.PasswordReset = nil
+	user.TokenVersion++
+	if err := uc.repo.Update(ctx, user); err != nil {
+		return "", err
+	}
+
+	return utils.GenerateJWT(user.ID.Hex(), user.Role, user.TokenVersion, mfa)
+}
+
+// RequestEmailChange sends a confirmation link to the new address. The account keeps
+// its current email until the link is followed.
+func (uc *AccountUsecase) RequestEmailChange(ctx context.Context, userID, newEmail, currentPassword string) error {
+	user, err := uc.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if err := uc.checkPassword(ctx, user, currentPassword); err != nil {
+		return err
+	}
+
+	existing, err := uc.repo.FindByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrEmailTaken
+	}
+
+	token, emailChange, err := newOneTimeToken(emailChangeTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	user.PendingEmail = newEmail
+	user.EmailChange = emailChange
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return uc.emailService.SendEmailChangeEmail(ctx, newEmail, user.Locale, token)
+	})
+}
+
+func (uc *AccountUsecase) ConfirmEmailChange(ctx context.Context, token string) error {
+	user, err := uc.repo.FindByEmailChangeTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if user == nil || user.EmailChange == nil || user.EmailChange.Expired(time.Now()) || user.PendingEmail == "" {
+		return ErrInvalidToken
+	}
+
+	// The address may have been registered by someone else since the change was requested
+	existing, err := uc.repo.FindByEmail(ctx, user.PendingEmail)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrEmailTaken
+	}
+
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	user.EmailChange = nil
+	user.IsVerified = true
+	return uc.repo.Update(ctx, user)
+}
+
+// DeleteAccount removes the user after checking their password. The invitations and
+// emails addressed to them go too, and the audit log keeps its entries without the
+// personal data; see AuditRepository.AnonymizeUser. The login-attempt state kept for
+// the account is cleared last.
+func (uc *AccountUsecase) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := uc.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if err := uc.checkPassword(ctx, user, password); err != nil {
+		return err
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.auditRepo.AnonymizeUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.invitationRepo.EraseUser(ctx, userID, user.Email); err != nil {
+			return err
+		}
+		for _, address := range userAddresses(user) {
+			if err := uc.deliveryRepo.DeleteByRecipient(ctx, address); err != nil {
+				return err
+			}
+			if err := uc.outboxRepo.DeletePendingEmails(ctx, address); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// The account is gone either way; leftover state only expires later
+	for _, key := range []string{accountAttemptKey(user.Email), mfaAttemptKey(userID)} {
+		if err := uc.attempts.Reset(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Failed to reset login attempts of deleted account", "user_id", userID, "error", err)
+		}
+	}
+	return nil
+}
+
+// checkPassword checks the password a signed-in user confirms a sensitive change
+// with. Failures count towards the lockout of the account as at login, so that a
+// stolen session cannot be used to guess the password.
+func (uc *AccountUsecase) checkPassword(ctx context.Context, user *models.User, password string) error {
+	key := accountAttemptKey(user.Email)
+	if err := checkLockout(ctx, uc.attempts, key); err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		if err := recordFailure(ctx, uc.attempts, key, maxAccountLoginFailures); err != nil {
+			return err
+		}
+		return ErrIncorrectPassword
+	}
+	return uc.attempts.Reset(ctx, key)
+}
+
+// Export returns everything stored about the user.
+func (uc *AccountUsecase) Export(ctx context.Context, userID string) (*dto.AccountExport, error) {
+	user, err := uc.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	activity, err := uc.auditRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := uc.invitationRepo.FindByUser(ctx, userID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []*models.EmailDelivery{}
+	queued := []dto.QueuedEmail{}
+	for _, address := range userAddresses(user) {
+		found, _, err := uc.deliveryRepo.Find(ctx, domain.EmailDeliveryFilter{To: address}, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, found...)
+
+		pending, err := uc.outboxRepo.FindPendingEmails(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range pending {
+			var email messaging.EmailMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &email); err != nil {
+				return nil, err
+			}
+			queued = append(queued, dto.QueuedEmail{
+				ID:       email.ID,
+				Type:     email.Type,
+				To:       email.To,
+				Subject:  email.Subject,
+				QueuedAt: msg.CreatedAt,
+			})
+		}
+	}
+
+	return &dto.AccountExport{
+		ExportedAt:             time.Now().UTC(),
+		Profile:                *toUserDTO(user),
+		PendingEmail:           user.PendingEmail,
+		VerificationPending:    user.Verification != nil,
+		PasswordResetPending:   user.PasswordReset != nil,
+		RecoveryCodesRemaining: len(user.MFA.RecoveryCodeHashes),
+		Activity:               activity,
+		Invitations:            invitations,
+		EmailDeliveries:        deliveries,
+		QueuedEmails:           queued,
+	}, nil
+}
+
+// userAddresses returns the addresses emails to the user may have been sent to.
+func userAddresses(user *models.User) []string {
+	if user.PendingEmail != "" && user.PendingEmail != user.Email {
+		return []string{user.Email, user.PendingEmail}
+	}
+	return []string{user.Email}
+}
@@ -0,0 +1,41 @@
+package application
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/messaging"
+)
+
+type AuditUsecase struct {
+	repo   domain.AuditRepository
+	outbox domain.OutboxRepository
+	tx     domain.Transactor
+	topic  string // Kafka topic to mirror entries to; publishing is off when empty
+}
+
+func NewAuditUsecase(repo domain.AuditRepository, outbox domain.OutboxRepository, tx domain.Transactor, topic string) *AuditUsecase {
+	return &AuditUsecase{repo: repo, outbox: outbox, tx: tx, topic: topic}
+}
+
+// Record appends the entry to the audit log and, when configured, queues it for
+// publishing to Kafka in the same transaction.
+func (uc *AuditUsecase) Record(ctx context.Context, entry *models.AuditEntry) error {
+	if uc.topic == "" {
+		return uc.repo.Append(ctx, entry)
+	}
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Append(ctx, entry); err != nil {
+			return err
+		}
+		msg, err := messaging.NewOutboxMessage(uc.topic, messaging.SchemaAuditEntry, entry.TargetType+":"+entry.TargetID, entry)
+		if err != nil {
+			return err
+		}
+		return uc.outbox.Add(ctx, msg)
+	})
+}
+
+func (uc *AuditUsecase) Find(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]*models.AuditEntry, int64, error) {
+	return uc.repo.Find(ctx, filter, page, limit)
+}
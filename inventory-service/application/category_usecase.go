@@ -1,34 +1,71 @@
 package application
 
 import (
+	"context"
 	"inventory-service/domain"
 	"inventory-service/domain/models"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/tracing"
 )
 
 type CategoryUsecase struct {
-	repo domain.CategoryRepository
+	repo   domain.CategoryRepository
+	tx     domain.Transactor
+	events *messaging.EventPublisher
 }
 
-func NewCategoryUsecase(repo domain.CategoryRepository) *CategoryUsecase {
-	return &CategoryUsecase{repo: repo}
+func NewCategoryUsecase(repo domain.CategoryRepository, tx domain.Transactor, events *messaging.EventPublisher) *CategoryUsecase {
+	return &CategoryUsecase{repo: repo, tx: tx, events: events}
 }
 
-func (u *CategoryUsecase) Create(category *models.Category) error {
-	return u.repo.Create(category)
+func (u *CategoryUsecase) Create(ctx context.Context, category *models.Category) (err error) {
+	ctx, span := startSpan(ctx, "CategoryUsecase.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, category); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, messaging.EventCategoryCreated, category.ID.Hex(), category)
+	})
 }
 
-func (u *CategoryUsecase) Update(category *models.Category) error {
-	return u.repo.Update(category)
+func (u *CategoryUsecase) Update(ctx context.Context, category *models.Category) (err error) {
+	ctx, span := startSpan(ctx, "CategoryUsecase.Update")
+	defer func() { tracing.End(span, err) }()
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, category); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, messaging.EventCategoryUpdated, category.ID.Hex(), category)
+	})
 }
 
-func (u *CategoryUsecase) Delete(id string) error {
-	return u.repo.Delete(id)
+func (u *CategoryUsecase) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "CategoryUsecase.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, messaging.EventCategoryDeleted, id, messaging.DeletedPayload{ID: id})
+	})
 }
 
-func (u *CategoryUsecase) GetByID(id string) (*models.Category, error) {
-	return u.repo.FindByID(id)
+// GetByID returns the category, or domain.ErrCategoryNotFound.
+func (u *CategoryUsecase) GetByID(ctx context.Context, id string) (*models.Category, error) {
+	category, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, domain.ErrCategoryNotFound
+	}
+	return category, nil
 }
 
-func (u *CategoryUsecase) GetAll() ([]*models.Category, error) {
-	return u.repo.FindAll()
+func (u *CategoryUsecase) GetAll(ctx context.Context) ([]*models.Category, error) {
+	return u.repo.FindAll(ctx)
 }
@@ -0,0 +1,20 @@
+package application
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+)
+
+// EmailDeliveryUsecase exposes the delivery status the email worker records.
+type EmailDeliveryUsecase struct {
+	repo domain.EmailDeliveryRepository
+}
+
+func NewEmailDeliveryUsecase(repo domain.EmailDeliveryRepository) *EmailDeliveryUsecase {
+	return &EmailDeliveryUsecase{repo: repo}
+}
+
+func (uc *EmailDeliveryUsecase) Find(ctx context.Context, filter domain.EmailDeliveryFilter, page, limit int) ([]*models.EmailDelivery, int64, error) {
+	return uc.repo.Find(ctx, filter, page, limit)
+}
@@ -0,0 +1,83 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/dto"
+	"inventory-service/infrastructure/mail"
+	"time"
+)
+
+var ErrInvalidTemplate = domain.NewValidationError("invalid email template")
+
+// EmailTemplateUsecase lets admins preview the email templates and override them in
+// Mongo. Overrides take precedence over the template directory and the built-in
+// templates.
+type EmailTemplateUsecase struct {
+	repo     domain.EmailTemplateRepository
+	renderer *mail.Renderer
+}
+
+func NewEmailTemplateUsecase(repo domain.EmailTemplateRepository, renderer *mail.Renderer) *EmailTemplateUsecase {
+	return &EmailTemplateUsecase{repo: repo, renderer: renderer}
+}
+
+func (uc *EmailTemplateUsecase) List() []string {
+	return mail.Names()
+}
+
+// Preview renders the template the given locale would currently receive, using
+// sample data.
+func (uc *EmailTemplateUsecase) Preview(ctx context.Context, name, locale string) (*dto.EmailTemplatePreviewDTO, error) {
+	rendered, err := uc.renderer.Preview(ctx, name, locale)
+	if err != nil {
+		return nil, err
+	}
+	return toPreviewDTO(name, locale, rendered), nil
+}
+
+// Save stores an override after checking that it renders with the sample data, and
+// returns its preview.
+func (uc *EmailTemplateUsecase) Save(ctx context.Context, name, updatedBy string, req *dto.SaveEmailTemplateDTO) (*dto.EmailTemplatePreviewDTO, error) {
+	rendered, err := uc.renderer.Validate(ctx, name, req.Locale, req.Source)
+	if errors.Is(err, mail.ErrUnknownTemplate) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	err = uc.repo.Upsert(ctx, &models.EmailTemplate{
+		Name:      name,
+		Locale:    req.Locale,
+		Source:    req.Source,
+		UpdatedBy: updatedBy,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPreviewDTO(name, req.Locale, rendered), nil
+}
+
+// Reset removes the override, restoring the template from the directory or the
+// built-in default.
+func (uc *EmailTemplateUsecase) Reset(ctx context.Context, name, locale string) error {
+	if _, ok := mail.SampleData[name]; !ok {
+		return mail.ErrUnknownTemplate
+	}
+	return uc.repo.Delete(ctx, name, locale)
+}
+
+func toPreviewDTO(name, locale string, rendered *mail.Rendered) *dto.EmailTemplatePreviewDTO {
+	return &dto.EmailTemplatePreviewDTO{
+		Name:    name,
+		Locale:  locale,
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	}
+}
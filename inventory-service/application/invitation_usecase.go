@@ -0,0 +1,174 @@
+package application
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/dto"
+	"inventory-service/infrastructure/services"
+	"inventory-service/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvitationNotFound   = domain.NewNotFoundError("invitation not found")
+	ErrInvitationPending    = domain.NewConflictError("a pending invitation already exists for this email")
+	ErrInvitationNotPending = domain.NewConflictError("invitation has already been accepted, revoked or has expired")
+	ErrInvalidInvitation    = domain.NewValidationError("invalid or expired invitation")
+)
+
+// InvitationUsecase lets admins invite staff who then set their own password and
+// start out verified with the role chosen by the admin.
+type InvitationUsecase struct {
+	repo         domain.InvitationRepository
+	userRepo     domain.UserRepository
+	tx           domain.Transactor
+	emailService services.EmailService
+}
+
+func NewInvitationUsecase(repo domain.InvitationRepository, userRepo domain.UserRepository, tx domain.Transactor, emailService services.EmailService) *InvitationUsecase {
+	return &InvitationUsecase{repo: repo, userRepo: userRepo, tx: tx, emailService: emailService}
+}
+
+func (uc *InvitationUsecase) Create(ctx context.Context, invitedBy string, req *dto.CreateInvitationDTO) (*dto.InvitationDTO, error) {
+	email := strings.TrimSpace(req.Email)
+
+	existingUser, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, ErrEmailTaken
+	}
+
+	pending, err := uc.repo.FindPendingByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, ErrInvitationPending
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation := &models.Invitation{
+		Email:     email,
+		Role:      req.Role,
+		Locale:    req.Locale,
+		TokenHash: utils.HashToken(token),
+		InvitedBy: invitedBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(invitationTTL),
+	}
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Create(ctx, invitation); err != nil {
+			return err
+		}
+		return uc.emailService.SendInvitationEmail(ctx, email, req.Locale, token, req.Role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toInvitationDTO(invitation), nil
+}
+
+func (uc *InvitationUsecase) GetAll(ctx context.Context, status string, page, limit int) ([]*dto.InvitationDTO, int64, error) {
+	invitations, total, err := uc.repo.FindAll(ctx, status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	dtos := make([]*dto.InvitationDTO, len(invitations))
+	for i, invitation := range invitations {
+		dtos[i] = toInvitationDTO(invitation)
+	}
+	return dtos, total, nil
+}
+
+func (uc *InvitationUsecase) Revoke(ctx context.Context, id string) error {
+	invitation, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if invitation == nil {
+		return ErrInvitationNotFound
+	}
+	if invitation.Status(time.Now()) != models.InvitationPending {
+		return ErrInvitationNotPending
+	}
+
+	now := time.Now()
+	invitation.RevokedAt = &now
+	return uc.repo.Update(ctx, invitation)
+}
+
+// Accept creates the invited user with the chosen password. The account is verified
+// straight away since the invitee proved ownership of the address by using the link.
+// The invitation is claimed before the user is created, so that when the link is used
+// twice at once only one request goes through.
+func (uc *InvitationUsecase) Accept(ctx context.Context, req *dto.AcceptInvitationDTO) error {
+	invitation, err := uc.repo.FindByTokenHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.Status(time.Now()) != models.InvitationPending {
+		return ErrInvalidInvitation
+	}
+
+	existingUser, err := uc.userRepo.FindByEmail(ctx, invitation.Email)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return ErrEmailTaken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:      invitation.Email,
+		Name:       strings.TrimSpace(req.Name),
+		Password:   string(hashedPassword),
+		Role:       invitation.Role,
+		Locale:     invitation.Locale,
+		IsVerified: true,
+		CreatedAt:  now,
+	}
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		accepted, err := uc.repo.MarkAccepted(ctx, invitation.ID.Hex(), now)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvalidInvitation
+		}
+		return uc.userRepo.Create(ctx, user)
+	})
+}
+
+func toInvitationDTO(invitation *models.Invitation) *dto.InvitationDTO {
+	return &dto.InvitationDTO{
+		ID:         invitation.ID.Hex(),
+		Email:      invitation.Email,
+		Role:       invitation.Role,
+		Locale:     invitation.Locale,
+		Status:     invitation.Status(time.Now()),
+		InvitedBy:  invitation.InvitedBy,
+		CreatedAt:  invitation.CreatedAt,
+		ExpiresAt:  invitation.ExpiresAt,
+		AcceptedAt: invitation.AcceptedAt,
+		RevokedAt:  invitation.RevokedAt,
+	}
+}
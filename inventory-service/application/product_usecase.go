@@ -4,30 +4,68 @@ import (
 	"context"
 	"inventory-service/domain"
 	"inventory-service/domain/models"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/tracing"
 )
 
+// Each write and its event are committed together, so consumers never miss a change
+// nor hear of one that was rolled back.
 type ProductUsecase struct {
-	repo domain.ProductRepository
+	repo   domain.ProductRepository
+	tx     domain.Transactor
+	events *messaging.EventPublisher
 }
 
-func NewProductUsecase(repo domain.ProductRepository) *ProductUsecase {
-	return &ProductUsecase{repo: repo}
+func NewProductUsecase(repo domain.ProductRepository, tx domain.Transactor, events *messaging.EventPublisher) *ProductUsecase {
+	return &ProductUsecase{repo: repo, tx: tx, events: events}
 }
 
-func (u *ProductUsecase) Create(product *models.Product) error {
-	return u.repo.Create(product)
+func (u *ProductUsecase) Create(ctx context.Context, product *models.Product) (err error) {
+	ctx, span := startSpan(ctx, "ProductUsecase.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, product); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, messaging.EventProductCreated, product.ID.Hex(), product)
+	})
 }
 
-func (u *ProductUsecase) Update(product *models.Product) error {
-	return u.repo.Update(product)
+func (u *ProductUsecase) Update(ctx context.Context, product *models.Product) (err error) {
+	ctx, span := startSpan(ctx, "ProductUsecase.Update")
+	defer func() { tracing.End(span, err) }()
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, product); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, messaging.EventProductUpdated, product.ID.Hex(), product)
+	})
 }
 
-func (u *ProductUsecase) Delete(id string) error {
-	return u.repo.Delete(id)
+func (u *ProductUsecase) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "ProductUsecase.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, messaging.EventProductDeleted, id, messaging.DeletedPayload{ID: id})
+	})
 }
 
-func (u *ProductUsecase) GetByID(id string) (*models.Product, error) {
-	return u.repo.FindByID(id)
+// GetByID returns the product, or domain.ErrProductNotFound.
+func (u *ProductUsecase) GetByID(ctx context.Context, id string) (*models.Product, error) {
+	product, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, domain.ErrProductNotFound
+	}
+	return product, nil
 }
 
 func (u *ProductUsecase) GetAll(ctx context.Context, filter domain.ProductFilter, sort domain.ProductSort, page, limit int) ([]*models.Product, int64, error) {
@@ -3,19 +3,268 @@ package application
 import (
 	"context"
 	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/services"
+	"inventory-service/infrastructure/tracing"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// LowStockAlert configures the email sent when a product's stock drops to or below
+// Threshold. Alerts are off when there are no recipients.
+type LowStockAlert struct {
+	Threshold  int
+	Recipients []string
+}
+
+// Reasons recorded on stock.changed events.
+const (
+	StockReasonBulkUpdate     = "bulk_update"
+	StockReasonOrderPlaced    = "order_placed"
+	StockReasonOrderCancelled = "order_cancelled"
+	StockReasonOrderRefunded  = "order_refunded"
 )
 
 type StockUsecase struct {
-	repo domain.StockRepository
+	repo            domain.StockRepository
+	productRepo     domain.ProductRepository
+	reservations    domain.StockReservationRepository
+	processedEvents domain.ProcessedEventRepository
+	tx              domain.Transactor
+	events          *messaging.EventPublisher
+	emailService    services.EmailService
+	lowStock        LowStockAlert
 }
 
-func NewStockUsecase(repo domain.StockRepository) *StockUsecase {
-	return &StockUsecase{repo: repo}
+func NewStockUsecase(repo domain.StockRepository, productRepo domain.ProductRepository, reservations domain.StockReservationRepository, processedEvents domain.ProcessedEventRepository, tx domain.Transactor, events *messaging.EventPublisher, emailService services.EmailService, lowStock LowStockAlert) *StockUsecase {
+	return &StockUsecase{
+		repo:            repo,
+		productRepo:     productRepo,
+		reservations:    reservations,
+		processedEvents: processedEvents,
+		tx:              tx,
+		events:          events,
+		emailService:    emailService,
+		lowStock:        lowStock,
+	}
 }
 
+// BulkUpdateStock updates the stock and queues a stock.changed event per product, plus
+// any low-stock alerts, in one transaction.
 func (uc *StockUsecase) BulkUpdateStock(ctx context.Context, updates map[string]struct {
 	Quantity  int
 	Increment bool
-}) error {
-	return uc.repo.BulkUpdateStock(ctx, updates)
+}) (err error) {
+	ctx, span := startSpan(ctx, "StockUsecase.BulkUpdateStock", attribute.Int("stock.products", len(updates)))
+	defer func() { tracing.End(span, err) }()
+
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		levels, err := uc.repo.BulkUpdateStock(ctx, updates)
+		if err != nil {
+			return err
+		}
+		for productID, stock := range levels {
+			delta := updates[productID].Quantity
+			if !updates[productID].Increment {
+				delta = -delta
+			}
+			if err := uc.stockChanged(ctx, productID, delta, stock, StockReasonBulkUpdate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HandleOrderEvent reserves stock when an order is placed, sells it when the order is
+// paid and returns it when the order is cancelled or refunded. The event is recorded
+// as processed in the same transaction as its effects, so a redelivered event is
+// skipped; an event that does not fit the order's reservation is ignored.
+func (uc *StockUsecase) HandleOrderEvent(ctx context.Context, event messaging.OrderEvent) (err error) {
+	ctx, span := startSpan(ctx, "StockUsecase.HandleOrderEvent",
+		attribute.String("order.id", event.OrderID),
+		attribute.String("event.id", event.ID),
+		attribute.String("event.type", event.Type),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if event.OrderID == "" {
+		slog.WarnContext(ctx, "Ignoring order event without an order ID", "event_type", event.Type, "event_id", event.ID)
+		return nil
+	}
+
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		processed, err := uc.processedEvents.Exists(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if processed {
+			slog.InfoContext(ctx, "Skipping order event, already processed", "event_type", event.Type, "event_id", event.ID, "order_id", event.OrderID)
+			return nil
+		}
+
+		reservation, err := uc.reservations.FindByOrderID(ctx, event.OrderID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case event.Type == messaging.EventOrderPlaced && reservation == nil:
+			err = uc.reserve(ctx, event)
+		case event.Type == messaging.EventOrderPaid && inStatus(reservation, models.ReservationReserved):
+			err = uc.commit(ctx, reservation)
+		case event.Type == messaging.EventOrderCancelled && inStatus(reservation, models.ReservationReserved),
+			event.Type == messaging.EventOrderRefunded && inStatus(reservation, models.ReservationReserved):
+			err = uc.giveBack(ctx, reservation, -1, models.ReservationReleased, StockReasonOrderCancelled)
+		case event.Type == messaging.EventOrderRefunded && inStatus(reservation, models.ReservationCommitted):
+			err = uc.giveBack(ctx, reservation, 0, models.ReservationRestored, StockReasonOrderRefunded)
+		default:
+			status := "no"
+			if reservation != nil {
+				status = "a " + reservation.Status
+			}
+			slog.InfoContext(ctx, "Ignoring order event for the state of its stock reservation", "event_type", event.Type, "event_id", event.ID, "order_id", event.OrderID, "reservation", status)
+		}
+		if err != nil {
+			return err
+		}
+		return uc.processedEvents.Add(ctx, event.ID, event.Type)
+	})
+}
+
+func inStatus(reservation *models.StockReservation, status string) bool {
+	return reservation != nil && reservation.Status == status
+}
+
+// reserve holds the order's items, or none of them when any is short. The rejected
+// reservation is kept so that the order's later events are ignored.
+func (uc *StockUsecase) reserve(ctx context.Context, event messaging.OrderEvent) error {
+	now := time.Now()
+	reservation := &models.StockReservation{
+		OrderID:   event.OrderID,
+		Items:     mergeItems(event.Payload.Items),
+		Status:    models.ReservationReserved,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	levels := make([]int, 0, len(reservation.Items))
+	for i, item := range reservation.Items {
+		stock, ok, err := uc.repo.ReserveStock(ctx, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Return what was held so far explicitly, as the write cannot be rolled
+			// back where transactions are unsupported
+			for _, held := range reservation.Items[:i] {
+				if _, _, err := uc.repo.AdjustStock(ctx, held.ProductID, held.Quantity, -held.Quantity); err != nil {
+					return err
+				}
+			}
+			slog.InfoContext(ctx, "Rejecting stock reservation, product unknown or short of stock", "order_id", event.OrderID, "product_id", item.ProductID, "quantity", item.Quantity)
+			reservation.Status = models.ReservationRejected
+			return uc.reservations.Save(ctx, reservation)
+		}
+		levels = append(levels, stock)
+	}
+
+	for i, item := range reservation.Items {
+		if err := uc.stockChanged(ctx, item.ProductID, -item.Quantity, levels[i], StockReasonOrderPlaced); err != nil {
+			return err
+		}
+	}
+	return uc.reservations.Save(ctx, reservation)
+}
+
+// commit sells the held stock; the stock level itself was already lowered when the
+// order was placed.
+func (uc *StockUsecase) commit(ctx context.Context, reservation *models.StockReservation) error {
+	for _, item := range reservation.Items {
+		if _, _, err := uc.repo.AdjustStock(ctx, item.ProductID, 0, -item.Quantity); err != nil {
+			return err
+		}
+	}
+	reservation.Status = models.ReservationCommitted
+	reservation.UpdatedAt = time.Now()
+	return uc.reservations.Save(ctx, reservation)
+}
+
+// giveBack returns the order's items to stock, taking them out of the reserved stock
+// too when reservedSign is -1, and moves the reservation to status.
+func (uc *StockUsecase) giveBack(ctx context.Context, reservation *models.StockReservation, reservedSign int, status, reason string) error {
+	for _, item := range reservation.Items {
+		stock, ok, err := uc.repo.AdjustStock(ctx, item.ProductID, item.Quantity, reservedSign*item.Quantity)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			slog.WarnContext(ctx, "Not returning stock, the product no longer exists", "order_id", reservation.OrderID, "product_id", item.ProductID, "quantity", item.Quantity)
+			continue
+		}
+		if err := uc.stockChanged(ctx, item.ProductID, item.Quantity, stock, reason); err != nil {
+			return err
+		}
+	}
+	reservation.Status = status
+	reservation.UpdatedAt = time.Now()
+	return uc.reservations.Save(ctx, reservation)
+}
+
+// mergeItems adds up the quantities of repeated products, keeping the first-seen
+// order, and drops non-positive quantities.
+func mergeItems(items []messaging.OrderEventItem) []models.ReservationItem {
+	merged := make([]models.ReservationItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, models.ReservationItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return merged
+}
+
+// stockChanged queues the stock.changed event for a change of delta that left the
+// product at stock, and a low-stock alert if due.
+func (uc *StockUsecase) stockChanged(ctx context.Context, productID string, delta, stock int, reason string) error {
+	if err := uc.events.Publish(ctx, messaging.EventStockChanged, productID, messaging.StockChangedPayload{
+		ProductID: productID,
+		Delta:     delta,
+		Stock:     stock,
+		Reason:    reason,
+	}); err != nil {
+		return err
+	}
+	return uc.alertIfLow(ctx, productID, delta, stock)
+}
+
+// alertIfLow emails the low-stock recipients when a change of delta took the product
+// across the threshold, so an alert goes out once rather than on every sale.
+func (uc *StockUsecase) alertIfLow(ctx context.Context, productID string, delta, stock int) error {
+	if len(uc.lowStock.Recipients) == 0 || delta >= 0 {
+		return nil
+	}
+	if stock > uc.lowStock.Threshold || stock-delta <= uc.lowStock.Threshold {
+		return nil
+	}
+	product, err := uc.productRepo.FindByID(ctx, productID)
+	if err != nil || product == nil {
+		return err
+	}
+	// The product may come from the cache, which does not see the update yet
+	product.Stock = stock
+	for _, to := range uc.lowStock.Recipients {
+		if err := uc.emailService.SendLowStockEmail(ctx, to, "", product, uc.lowStock.Threshold); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,15 @@
+package application
+
+import (
+	"context"
+	"inventory-service/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts the span of a usecase operation, named e.g.
+// "StockUsecase.BulkUpdateStock"; it is ended with tracing.End.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
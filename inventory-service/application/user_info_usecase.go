@@ -8,11 +8,12 @@ import (
 )
 
 type UserInfoUsecase struct {
-	repo domain.UserInfoRepository
+	repo     domain.UserInfoRepository
+	attempts domain.LoginAttemptRepository
 }
 
-func NewUserInfoUsecase(repo domain.UserInfoRepository) *UserInfoUsecase {
-	return &UserInfoUsecase{repo: repo}
+func NewUserInfoUsecase(repo domain.UserInfoRepository, attempts domain.LoginAttemptRepository) *UserInfoUsecase {
+	return &UserInfoUsecase{repo: repo, attempts: attempts}
 }
 
 func (uc *UserInfoUsecase) GetByID(ctx context.Context, id string) (*dto.UserDTO, error) {
@@ -23,16 +24,16 @@ func (uc *UserInfoUsecase) GetByID(ctx context.Context, id string) (*dto.UserDTO
 	return toUserDTO(user), nil
 }
 
-func (uc *UserInfoUsecase) GetAll(ctx context.Context) ([]*dto.UserDTO, error) {
-	users, err := uc.repo.GetAll(ctx)
+func (uc *UserInfoUsecase) GetAll(ctx context.Context, filter domain.UserFilter, sort domain.UserSort, page, limit int) ([]*dto.UserDTO, int64, error) {
+	users, total, err := uc.repo.GetAll(ctx, filter, sort, page, limit)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	dtos := make([]*dto.UserDTO, len(users))
 	for i, user := range users {
 		dtos[i] = toUserDTO(user)
 	}
-	return dtos, nil
+	return dtos, total, nil
 }
 
 func (uc *UserInfoUsecase) Update(ctx context.Context, id string, req *dto.UpdateUserRequest) (*dto.UserDTO, error) {
@@ -58,11 +59,31 @@ func (uc *UserInfoUsecase) Delete(ctx context.Context, id string) error {
 	return uc.repo.Delete(ctx, id)
 }
 
+// Unlock clears the failed-login counter and any lockout on the user's account.
+func (uc *UserInfoUsecase) Unlock(ctx context.Context, id string) error {
+	user, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return uc.attempts.Reset(ctx, accountAttemptKey(user.Email))
+}
+
 func toUserDTO(user *models.User) *dto.UserDTO {
+	createdAt := user.CreatedAt
+	if createdAt.IsZero() {
+		// Accounts created before created_at was recorded and not backfilled yet; the
+		// ObjectID carries the insert time
+		createdAt = user.ID.Timestamp()
+	}
 	return &dto.UserDTO{
-		ID:         user.ID.Hex(),
-		Email:      user.Email,
-		Role:       user.Role,
-		IsVerified: user.IsVerified,
+		ID:          user.ID.Hex(),
+		Email:       user.Email,
+		Name:        user.Name,
+		Role:        user.Role,
+		Locale:      user.Locale,
+		IsVerified:  user.IsVerified,
+		MFAEnabled:  user.MFA.Enabled,
+		CreatedAt:   createdAt,
+		LastLoginAt: user.LastLoginAt,
 	}
 }
@@ -1,107 +1,515 @@
 package application
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
+	"fmt"
 	"inventory-service/domain"
 	"inventory-service/domain/models"
+	"inventory-service/infrastructure/dto"
 	"inventory-service/infrastructure/services"
+	"inventory-service/infrastructure/tracing"
 	"inventory-service/utils"
+	"slices"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	verificationTokenTTL       = 24 * time.Hour
+	resetTokenTTL              = time.Hour
+	verificationResendInterval = time.Minute
+
+	maxAccountLoginFailures = 5
+	maxIPLoginFailures      = 20
+	loginFailureWindow      = 15 * time.Minute
+	baseLockoutDuration     = time.Minute
+	maxLockoutDuration      = time.Hour
+
+	maxMFAFailures    = 5
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidToken       = domain.NewValidationError("invalid or expired token")
+	ErrResendThrottled    = domain.NewRateLimitedError("a verification email was requested for this address recently, please try again later")
+	ErrInvalidCredentials = domain.NewUnauthorizedError("invalid email or password")
+	ErrEmailNotVerified   = domain.NewForbiddenError("email address has not been verified")
+	ErrInvalidMFAToken    = domain.NewUnauthorizedError("invalid or expired two-factor authentication token, please log in again")
+	ErrIncorrectMFACode   = domain.NewUnauthorizedError("incorrect two-factor authentication code") // At login
+	ErrInvalidMFACode     = domain.NewValidationError("invalid two-factor authentication code")     // When enrolling or disabling
+	ErrMFAAlreadyEnabled  = domain.NewConflictError("two-factor authentication is already enabled")
+	ErrMFANotEnabled      = domain.NewConflictError("two-factor authentication is not enabled")
+	ErrMFANotPending      = domain.NewConflictError("no two-factor enrolment in progress")
+)
+
+// MFAPolicy configures TOTP enrolment and which roles must use it.
+type MFAPolicy struct {
+	Issuer        string   // Shown as the account issuer in authenticator apps
+	RequiredRoles []string // Roles whose sessions must pass a second factor
+}
+
+// TooManyAttemptsError is returned while an account or client IP is locked out.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyAttemptsError) Is(target error) bool { return target == domain.ErrRateLimited }
+
+// dummyPasswordHash is compared against when the email is unknown so that the
+// response time does not reveal whether an account exists.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
 type UserUsecase struct {
 	repo         domain.UserRepository
+	attempts     domain.LoginAttemptRepository
+	tx           domain.Transactor
 	emailService services.EmailService
+	mfaPolicy    MFAPolicy
 }
 
-func NewUserUsecase(repo domain.UserRepository, emailService services.EmailService) *UserUsecase {
-	return &UserUsecase{repo: repo, emailService: emailService}
+func NewUserUsecase(repo domain.UserRepository, attempts domain.LoginAttemptRepository, tx domain.Transactor, emailService services.EmailService, mfaPolicy MFAPolicy) *UserUsecase {
+	return &UserUsecase{repo: repo, attempts: attempts, tx: tx, emailService: emailService, mfaPolicy: mfaPolicy}
 }
 
-func (u *UserUsecase) Register(email, password string) error {
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func mfaAttemptKey(userID string) string {
+	return "mfa:" + userID
+}
+
+func resendAttemptKey(email string) string {
+	return "resend:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// newOneTimeToken generates a token to email to the user along with the hashed form to store.
+func newOneTimeToken(ttl time.Duration) (string, *models.OneTimeToken, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	return token, &models.OneTimeToken{
+		Hash:      utils.HashToken(token),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+func (u *UserUsecase) Register(ctx context.Context, email, password, locale string) (err error) {
+	ctx, span := startSpan(ctx, "UserUsecase.Register")
+	defer func() { tracing.End(span, err) }()
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	tokenBytes := make([]byte, 16)
-	_, err = rand.Read(tokenBytes)
+	verificationToken, verification, err := newOneTimeToken(verificationTokenTTL)
 	if err != nil {
 		return err
 	}
-	verificationToken := hex.EncodeToString(tokenBytes)
 
 	user := &models.User{
-		Email:             email,
-		Password:          string(hashedPassword),
-		Role:              "user",
-		IsVerified:        false,
-		VerificationToken: verificationToken,
+		Email:        email,
+		Password:     string(hashedPassword),
+		Role:         "user",
+		Locale:       locale,
+		IsVerified:   false,
+		Verification: verification,
+		CreatedAt:    time.Now(),
+	}
+
+	// The user and the verification email are committed together, so an outage of
+	// the email pipeline cannot leave an account nobody can verify
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, user); err != nil {
+			return err
+		}
+		return u.emailService.SendVerificationEmail(ctx, email, locale, verificationToken)
+	})
+}
+
+// Login authenticates a user. Unknown emails and wrong passwords both yield
+// ErrInvalidCredentials and count towards the lockout of the account and client IP;
+// ErrEmailNotVerified is only reported once the password has been checked.
+// Users with two-factor authentication get an MFA challenge token instead of a session.
+func (u *UserUsecase) Login(ctx context.Context, email, password, ip string) (_ *dto.LoginResponse, err error) {
+	ctx, span := startSpan(ctx, "UserUsecase.Login")
+	defer func() { tracing.End(span, err) }()
+
+	accountKey, ipKey := accountAttemptKey(email), ipAttemptKey(ip)
+	if err := checkLockout(ctx, u.attempts, accountKey, ipKey); err != nil {
+		return nil, err
 	}
 
-	err = u.repo.Create(user)
+	user, err := u.repo.FindByEmail(ctx, email)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if user == nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, u.recordLoginFailure(ctx, accountKey, ipKey)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return nil, u.recordLoginFailure(ctx, accountKey, ipKey)
+	}
+
+	if !user.IsVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	if err := u.attempts.Reset(ctx, accountKey); err != nil {
+		return nil, err
 	}
 
-	return u.emailService.SendVerificationEmail(email, verificationToken)
+	if user.MFA.Enabled {
+		mfaToken, err := utils.GenerateMFAChallengeJWT(user.ID.Hex(), user.TokenVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, user.TokenVersion, false)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{
+		Token:                 token,
+		MFAEnrollmentRequired: slices.Contains(u.mfaPolicy.RequiredRoles, user.Role),
+	}, nil
 }
 
-func (u *UserUsecase) Login(email, password string) (string, error) {
-	user, err := u.repo.FindByEmail(email)
-	if err != nil || !user.IsVerified {
+// VerifyMFA completes a two-step login by checking a TOTP or recovery code against
+// the user named in the challenge token and issuing a full session token.
+func (u *UserUsecase) VerifyMFA(ctx context.Context, mfaToken, code string) (string, error) {
+	claims, err := utils.ValidateMFAChallengeJWT(mfaToken)
+	if err != nil {
+		return "", ErrInvalidMFAToken
+	}
+
+	mfaKey := mfaAttemptKey(claims.UserID)
+	if err := checkLockout(ctx, u.attempts, mfaKey); err != nil {
 		return "", err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	user, err := u.repo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || user.TokenVersion != claims.TokenVersion || !user.MFA.Enabled {
+		return "", ErrInvalidMFAToken
+	}
+
+	if !consumeMFACode(user, code) {
+		if err := recordFailure(ctx, u.attempts, mfaKey, maxMFAFailures); err != nil {
+			return "", err
+		}
+		return "", ErrIncorrectMFACode
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := u.repo.Update(ctx, user); err != nil {
+		return "", err
+	}
+	if err := u.attempts.Reset(ctx, mfaKey); err != nil {
+		return "", err
+	}
+
+	return utils.GenerateJWT(user.ID.Hex(), user.Role, user.TokenVersion, true)
+}
+
+// consumeMFACode accepts a TOTP code newer than the last one used, or an unused
+// recovery code, and records its use on the user.
+func consumeMFACode(user *models.User, code string) bool {
+	if step, ok := utils.ValidateTOTP(user.MFA.Secret, code, time.Now()); ok && step > user.MFA.LastUsedStep {
+		user.MFA.LastUsedStep = step
+		return true
+	}
+
+	hash := utils.HashToken(utils.NormalizeRecoveryCode(code))
+	if i := slices.Index(user.MFA.RecoveryCodeHashes, hash); i >= 0 {
+		user.MFA.RecoveryCodeHashes = slices.Delete(user.MFA.RecoveryCodeHashes, i, i+1)
+		return true
+	}
+	return false
+}
+
+// BeginMFAEnrollment generates a new TOTP secret for the user to add to an
+// authenticator app. It only takes effect once confirmed with a valid code.
+func (u *UserUsecase) BeginMFAEnrollment(ctx context.Context, userID string) (*dto.MFAEnrollmentResponse, error) {
+	user, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.MFA.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFA.PendingSecret = secret
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &dto.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(u.mfaPolicy.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFAEnrollment enables two-factor authentication once the user proves their
+// authenticator works. Every existing session is logged out; the recovery codes are
+// returned only this once, with a fresh session token marked as MFA-verified so the
+// user can carry on without logging in again.
+func (u *UserUsecase) ConfirmMFAEnrollment(ctx context.Context, userID, code string) (*dto.MFAConfirmationResponse, error) {
+	user, err := u.repo.FindByID(ctx, userID)
 	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.MFA.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFA.PendingSecret == "" {
+		return nil, ErrMFANotPending
+	}
+
+	step, ok := utils.ValidateTOTP(user.MFA.PendingSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))
+	}
+
+	user.MFA = models.MFASettings{
+		Enabled:            true,
+		Secret:             user.MFA.PendingSecret,
+		LastUsedStep:       step,
+		RecoveryCodeHashes: hashes,
+	}
+	user.TokenVersion++ // Log out the sessions that never passed a second factor
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, user.TokenVersion, true)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MFAConfirmationResponse{RecoveryCodes: recoveryCodes, Token: token}, nil
+}
+
+// DisableMFA turns off two-factor authentication after checking a current code. Every
+// other session is logged out; the returned token replaces the caller's.
+func (u *UserUsecase) DisableMFA(ctx context.Context, userID, code string) (string, error) {
+	user, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+	if !user.MFA.Enabled {
+		return "", ErrMFANotEnabled
+	}
+
+	// Counted like codes at login, so that a session cannot brute-force its way out of
+	// two-factor authentication
+	mfaKey := mfaAttemptKey(userID)
+	if err := checkLockout(ctx, u.attempts, mfaKey); err != nil {
+		return "", err
+	}
+	if !consumeMFACode(user, code) {
+		if err := recordFailure(ctx, u.attempts, mfaKey, maxMFAFailures); err != nil {
+			return "", err
+		}
+		return "", ErrInvalidMFACode
+	}
+
+	user.MFA = models.MFASettings{}
+	user.TokenVersion++
+	if err := u.repo.Update(ctx, user); err != nil {
 		return "", err
 	}
+	if err := u.attempts.Reset(ctx, mfaKey); err != nil {
+		return "", err
+	}
+	// Not MFA-verified, so that roles required to use two-factor authentication must
+	// enrol again
+	return utils.GenerateJWT(user.ID.Hex(), user.Role, user.TokenVersion, false)
+}
 
-	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role)
-	return token, err
+// checkLockout reports a TooManyAttemptsError while any of keys is locked out.
+func checkLockout(ctx context.Context, attempts domain.LoginAttemptRepository, keys ...string) error {
+	for _, key := range keys {
+		lockedFor, err := attempts.LockedFor(ctx, key)
+		if err != nil {
+			return err
+		}
+		if lockedFor > 0 {
+			return &TooManyAttemptsError{RetryAfter: lockedFor}
+		}
+	}
+	return nil
 }
 
-func (u *UserUsecase) VerifyEmail(token string) error {
-	user, err := u.repo.FindByVerificationToken(token)
+// recordFailure counts a failed attempt against key and locks it out once it reaches
+// limit, doubling the lockout for every further failure.
+func recordFailure(ctx context.Context, attempts domain.LoginAttemptRepository, key string, limit int64) error {
+	failures, err := attempts.RecordFailure(ctx, key, loginFailureWindow)
 	if err != nil {
 		return err
 	}
+	if failures < limit {
+		return nil
+	}
+	return attempts.Lock(ctx, key, lockoutDuration(failures-limit))
+}
+
+// recordLoginFailure counts a failed login against both the account and the IP.
+func (u *UserUsecase) recordLoginFailure(ctx context.Context, accountKey, ipKey string) error {
+	if err := recordFailure(ctx, u.attempts, accountKey, maxAccountLoginFailures); err != nil {
+		return err
+	}
+	if err := recordFailure(ctx, u.attempts, ipKey, maxIPLoginFailures); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+func lockoutDuration(excessFailures int64) time.Duration {
+	duration := baseLockoutDuration
+	for i := int64(0); i < excessFailures && duration < maxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxLockoutDuration {
+		return maxLockoutDuration
+	}
+	return duration
+}
+
+func (u *UserUsecase) VerifyEmail(ctx context.Context, token string) error {
+	user, err := u.repo.FindByVerificationTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Verification == nil || user.Verification.Expired(time.Now()) {
+		return ErrInvalidToken
+	}
 
 	user.IsVerified = true
-	user.VerificationToken = ""
-	return u.repo.Update(user)
+	user.Verification = nil
+	return u.repo.Update(ctx, user)
 }
 
-func (u *UserUsecase) RequestPasswordReset(email string) error {
-	user, err := u.repo.FindByEmail(email)
+// ResendVerification issues a fresh verification token, replacing any earlier one.
+// Requests are throttled per address whether or not it is registered, and unknown or
+// already verified addresses are ignored, so the endpoint cannot be used to discover
+// which emails are registered.
+func (u *UserUsecase) ResendVerification(ctx context.Context, email string) error {
+	resendKey := resendAttemptKey(email)
+	lockedFor, err := u.attempts.LockedFor(ctx, resendKey)
+	if err != nil {
+		return err
+	}
+	if lockedFor > 0 {
+		return ErrResendThrottled
+	}
+	if err := u.attempts.Lock(ctx, resendKey, verificationResendInterval); err != nil {
+		return err
+	}
+
+	user, err := u.repo.FindByEmail(ctx, email)
 	if err != nil {
 		return err
 	}
+	if user == nil || user.IsVerified {
+		return nil
+	}
 
-	tokenBytes := make([]byte, 16)
-	_, err = rand.Read(tokenBytes)
+	verificationToken, verification, err := newOneTimeToken(verificationTokenTTL)
 	if err != nil {
 		return err
 	}
-	resetToken := hex.EncodeToString(tokenBytes)
 
-	user.ResetToken = resetToken
-	err = u.repo.Update(user)
+	user.Verification = verification
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return u.emailService.SendVerificationEmail(ctx, email, user.Locale, verificationToken)
+	})
+}
+
+func (u *UserUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := u.repo.FindByEmail(ctx, email)
 	if err != nil {
 		return err
 	}
+	if user == nil {
+		return nil
+	}
 
-	return u.emailService.SendPasswordResetEmail(email, resetToken)
+	resetToken, passwordReset, err := newOneTimeToken(resetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordReset = passwordReset
+	return u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return u.emailService.SendPasswordResetEmail(ctx, email, user.Locale, resetToken)
+	})
 }
 
-func (u *UserUsecase) ResetPassword(token, newPassword string) error {
-	user, err := u.repo.FindByResetToken(token)
+func (u *UserUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	user, err := u.repo.FindByResetTokenHash(ctx, utils.HashToken(token))
 	if err != nil {
 		return err
 	}
+	if user == nil || user.PasswordReset == nil || user.PasswordReset.Expired(time.Now()) {
+		return ErrInvalidToken
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -109,6 +517,7 @@ func (u *UserUsecase) ResetPassword(token, newPassword string) error {
 	}
 
 	user.Password = string(hashedPassword)
-	user.ResetToken = ""
-	return u.repo.Update(user)
+	user.PasswordReset = nil
+	user.TokenVersion++ // Log out every existing session
+	return u.repo.Update(ctx, user)
 }
@@ -0,0 +1,242 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"inventory-service/domain/models"
+	"inventory-service/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDisableMFALocksOutAfterTooManyBadCodes(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{
+		ID:    primitive.NewObjectID(),
+		Email: "jane@example.com",
+		Role:  "user",
+		MFA:   models.MFASettings{Enabled: true, Secret: secret},
+	}
+	users := &memoryUsers{byID: map[string]*models.User{user.ID.Hex(): user}}
+	attempts := newMemoryAttempts()
+	uc := NewUserUsecase(users, attempts, nil, nil, MFAPolicy{})
+	ctx := context.Background()
+	bad := wrongTOTPCode(t, secret)
+
+	for i := 1; i < maxMFAFailures; i++ {
+		if _, err := uc.DisableMFA(ctx, user.ID.Hex(), bad); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("bad code %d: DisableMFA = %v, want ErrInvalidMFACode", i, err)
+		}
+	}
+	_, err = uc.DisableMFA(ctx, user.ID.Hex(), bad)
+	if !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("bad code %d: DisableMFA = %v, want ErrInvalidMFACode", maxMFAFailures, err)
+	}
+
+	// Locked out now, even with the right code
+	good, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.DisableMFA(ctx, user.ID.Hex(), good)
+	var tooMany *TooManyAttemptsError
+	if !errors.As(err, &tooMany) {
+		t.Fatalf("DisableMFA after %d bad codes = %v, want TooManyAttemptsError", maxMFAFailures, err)
+	}
+	if !users.byID[user.ID.Hex()].MFA.Enabled {
+		t.Error("two-factor authentication was disabled")
+	}
+}
+
+func TestDisableMFAInvalidatesSessions(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{
+		ID:           primitive.NewObjectID(),
+		Email:        "jane@example.com",
+		Role:         "user",
+		TokenVersion: 3,
+		MFA:          models.MFASettings{Enabled: true, Secret: secret},
+	}
+	users := &memoryUsers{byID: map[string]*models.User{user.ID.Hex(): user}}
+	uc := NewUserUsecase(users, newMemoryAttempts(), nil, nil, MFAPolicy{})
+
+	good, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := uc.DisableMFA(context.Background(), user.ID.Hex(), good)
+	if err != nil {
+		t.Fatalf("DisableMFA: %v", err)
+	}
+
+	stored := users.byID[user.ID.Hex()]
+	if stored.MFA.Enabled {
+		t.Error("two-factor authentication is still enabled")
+	}
+	if stored.TokenVersion != 4 {
+		t.Errorf("token version = %d, want 4", stored.TokenVersion)
+	}
+	claims, err := utils.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("returned token: %v", err)
+	}
+	if claims.TokenVersion != stored.TokenVersion {
+		t.Errorf("returned token has version %d, want %d", claims.TokenVersion, stored.TokenVersion)
+	}
+}
+
+func TestConfirmMFAEnrollmentInvalidatesSessions(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{
+		ID:           primitive.NewObjectID(),
+		Email:        "jane@example.com",
+		Role:         "user",
+		TokenVersion: 3,
+		MFA:          models.MFASettings{PendingSecret: secret},
+	}
+	users := &memoryUsers{byID: map[string]*models.User{user.ID.Hex(): user}}
+	uc := NewUserUsecase(users, newMemoryAttempts(), nil, nil, MFAPolicy{})
+
+	good, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmation, err := uc.ConfirmMFAEnrollment(context.Background(), user.ID.Hex(), good)
+	if err != nil {
+		t.Fatalf("ConfirmMFAEnrollment: %v", err)
+	}
+
+	stored := users.byID[user.ID.Hex()]
+	if !stored.MFA.Enabled {
+		t.Error("two-factor authentication is not enabled")
+	}
+	if stored.TokenVersion != 4 {
+		t.Errorf("token version = %d, want 4", stored.TokenVersion)
+	}
+	claims, err := utils.ValidateJWT(confirmation.Token)
+	if err != nil {
+		t.Fatalf("returned token: %v", err)
+	}
+	if claims.TokenVersion != stored.TokenVersion || !claims.MFA {
+		t.Errorf("returned token has version %d and MFA %t, want %d and true", claims.TokenVersion, claims.MFA, stored.TokenVersion)
+	}
+}
+
+// wrongTOTPCode returns a code that is not accepted for secret around now.
+func wrongTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	for _, candidate := range []string{"000000", "111111", "222222", "333333"} {
+		if _, ok := utils.ValidateTOTP(secret, candidate, time.Now()); !ok {
+			return candidate
+		}
+	}
+	t.Fatal("no wrong code found")
+	return ""
+}
+
+type memoryUsers struct {
+	byID map[string]*models.User
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	copied := *user
+	r.byID[user.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Email == email }), nil
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.ID.Hex() == id }), nil
+}
+
+func (r *memoryUsers) Update(ctx context.Context, user *models.User) error {
+	copied := *user
+	r.byID[user.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *memoryUsers) Delete(ctx context.Context, id string) error {
+	delete(r.byID, id)
+	return nil
+}
+
+func (r *memoryUsers) FindByVerificationTokenHash(ctx context.Context, hash string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Verification != nil && user.Verification.Hash == hash }), nil
+}
+
+func (r *memoryUsers) FindByResetTokenHash(ctx context.Context, hash string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.PasswordReset != nil && user.PasswordReset.Hash == hash }), nil
+}
+
+func (r *memoryUsers) FindByEmailChangeTokenHash(ctx context.Context, hash string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.EmailChange != nil && user.EmailChange.Hash == hash }), nil
+}
+
+func (r *memoryUsers) find(match func(*models.User) bool) *models.User {
+	for _, user := range r.byID {
+		if match(user) {
+			copied := *user
+			return &copied
+		}
+	}
+	return nil
+}
+
+type memoryAttempts struct {
+	mu       sync.Mutex
+	failures map[string]int64
+	lockouts map[string]time.Time
+}
+
+func newMemoryAttempts() *memoryAttempts {
+	return &memoryAttempts{failures: make(map[string]int64), lockouts: make(map[string]time.Time)}
+}
+
+func (r *memoryAttempts) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[key]++
+	return r.failures[key], nil
+}
+
+func (r *memoryAttempts) Lock(ctx context.Context, key string, duration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lockouts[key] = time.Now().Add(duration)
+	return nil
+}
+
+func (r *memoryAttempts) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d := time.Until(r.lockouts[key]); d > 0 {
+		return d, nil
+	}
+	return 0, nil
+}
+
+func (r *memoryAttempts) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, key)
+	delete(r.lockouts, key)
+	return nil
+}
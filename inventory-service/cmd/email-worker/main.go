@@ -0,0 +1,154 @@
+// Command email-worker delivers the emails queued on the email topic.
+//
+// Usage:
+//
+//	email-worker [run] [flags]   consume the email topic until interrupted
+//	email-worker replay [flags]  move the messages in the dead-letter topic back to the email topic
+//
+// The flags are the configuration flags of the service, e.g. --config or --print-config.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"inventory-service/infrastructure/config"
+	"inventory-service/infrastructure/db"
+	"inventory-service/infrastructure/logging"
+	"inventory-service/infrastructure/mail"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/repository"
+	"inventory-service/infrastructure/tracing"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	consumerGroup = "email-consumer-group"
+	replayGroup   = "email-dlq-replay"
+	maxBackoff    = time.Minute
+)
+
+func main() {
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	cfg, err := config.LoadConfig(args)
+	if errors.Is(err, config.ErrPrintConfig) || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	if err := messaging.CompileSchemas(); err != nil {
+		fatal("Failed to load message schemas", err)
+	}
+
+	// Trace the emails handled; the spans still buffered are flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), "inventory-email-worker", cfg.TracingExporter, cfg.TracingOTLPEndpoint)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "run":
+		err = run(ctx, cfg)
+	case "replay":
+		err = replay(ctx, cfg)
+	default:
+		err = fmt.Errorf("unknown command %q, expected run or replay", command)
+	}
+	if err != nil {
+		fatal("Email worker failed", err)
+	}
+}
+
+// fatal logs err and exits; deferred calls do not run.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func run(ctx context.Context, cfg *config.Config) error {
+	mongoClient, err := db.NewMongoClient(cfg.MongoURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer mongoClient.Disconnect()
+
+	producer, err := messaging.NewKafkaProducer(cfg.KafkaBrokers)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	defer producer.Close()
+
+	transport, err := mail.NewTransport(cfg)
+	if err != nil {
+		return err
+	}
+
+	deliveryRepo := repository.NewEmailDeliveryRepository(mongoClient, "inventory_db", "email_deliveries")
+	consumer := messaging.NewEmailConsumer(producer, deliveryRepo, emailSender(transport, cfg.EmailFrom), cfg.KafkaEmailDLQTopic, messaging.RetryPolicy{
+		MaxAttempts: cfg.EmailMaxAttempts,
+		Backoff:     cfg.EmailRetryBackoff,
+		MaxBackoff:  maxBackoff,
+	})
+
+	slog.Info("Email worker consuming", "topic", cfg.KafkaEmailTopic, "transport", cfg.MailTransport, "dlq_topic", cfg.KafkaEmailDLQTopic)
+	return consumer.Run(ctx, messaging.NewKafkaSubscriber(cfg.KafkaBrokers), consumerGroup, cfg.KafkaEmailTopic)
+}
+
+func replay(ctx context.Context, cfg *config.Config) error {
+	producer, err := messaging.NewKafkaProducer(cfg.KafkaBrokers)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	defer producer.Close()
+
+	count, err := messaging.ReplayDeadLetters(ctx, cfg.KafkaBrokers, replayGroup, cfg.KafkaEmailDLQTopic, cfg.KafkaEmailTopic, producer)
+	slog.Info("Replayed dead letters", "count", count, "from", cfg.KafkaEmailDLQTopic, "to", cfg.KafkaEmailTopic)
+	return err
+}
+
+// emailSender adapts the mail transport to the consumer, marking failures that
+// retrying cannot fix as permanent.
+func emailSender(transport mail.Transport, from string) messaging.EmailSender {
+	return func(ctx context.Context, msg messaging.EmailMessage) error {
+		err := transport.Send(ctx, &mail.Message{
+			From:    from,
+			To:      msg.To,
+			Subject: msg.Subject,
+			Text:    msg.Body,
+			HTML:    msg.HTMLBody,
+		})
+		if mail.IsPermanent(err) {
+			return &messaging.PermanentError{Err: err}
+		}
+		return err
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/config"
+	"inventory-service/infrastructure/mail"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/services"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pipeline runs an email from the EmailService through the outbox relay and the
+// broker to the email consumer, with everything but the transport in memory.
+type pipeline struct {
+	cfg        *config.Config
+	emails     services.EmailService
+	outbox     *memoryOutbox
+	broker     *messaging.MemoryBroker
+	deliveries *memoryDeliveries
+}
+
+func startPipeline(t *testing.T, transport mail.Transport) *pipeline {
+	t.Helper()
+	if err := messaging.CompileSchemas(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig([]string{
+		"--config", "/dev/null",
+		"--mongo-url", "mongodb://localhost:27017",
+		"--redis-url", "redis://localhost:6379",
+		"--kafka-broker", "localhost:9092",
+		"--service-api-key", "test",
+		"--public-ui-url", "https://inventory.example.com",
+		"--email-from", "inventory@example.com",
+		"--email-max-attempts", "3",
+		"--email-retry-backoff", "1ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &pipeline{
+		cfg:        cfg,
+		outbox:     &memoryOutbox{},
+		broker:     messaging.NewMemoryBroker(1),
+		deliveries: &memoryDeliveries{byID: make(map[string]*models.EmailDelivery)},
+	}
+	p.emails = services.NewEmailService(cfg, p.outbox, mail.NewRenderer(mail.DefaultStore()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	consumer := messaging.NewEmailConsumer(p.broker, p.deliveries, emailSender(transport, cfg.EmailFrom), cfg.KafkaEmailDLQTopic, messaging.RetryPolicy{
+		MaxAttempts: cfg.EmailMaxAttempts,
+		Backoff:     cfg.EmailRetryBackoff,
+		MaxBackoff:  maxBackoff,
+	})
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		messaging.NewOutboxRelay(p.outbox, p.broker, 10*time.Millisecond).Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		consumer.Run(ctx, p.broker, consumerGroup, cfg.KafkaEmailTopic)
+	}()
+	return p
+}
+
+func TestEmailPipeline(t *testing.T) {
+	transport := mail.NewMemoryTransport()
+	p := startPipeline(t, transport)
+
+	if err := p.emails.SendVerificationEmail(context.Background(), "jane@example.com", "", "s3cret/token"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the email to be sent", func() bool { return len(transport.Messages()) > 0 })
+
+	sent := transport.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sent))
+	}
+	msg := sent[0]
+	link := "https://inventory.example.com/inventory/api/users/verify/s3cret%2Ftoken"
+	if msg.From != "inventory@example.com" {
+		t.Errorf("From = %q, want inventory@example.com", msg.From)
+	}
+	if msg.To != "jane@example.com" {
+		t.Errorf("To = %q, want jane@example.com", msg.To)
+	}
+	if msg.Subject != "Verify Your Email" {
+		t.Errorf("Subject = %q, want %q", msg.Subject, "Verify Your Email")
+	}
+	if !strings.Contains(msg.Text, link) || !strings.Contains(msg.Text, "jane@example.com") {
+		t.Errorf("Text does not contain the recipient and %s:\n%s", link, msg.Text)
+	}
+	if !strings.Contains(msg.HTML, `href="`+link+`"`) {
+		t.Errorf("HTML does not link to %s:\n%s", link, msg.HTML)
+	}
+
+	waitFor(t, "the delivery to be recorded", func() bool {
+		deliveries, _, _ := p.deliveries.Find(context.Background(), domain.EmailDeliveryFilter{Status: models.EmailDeliverySent}, 1, 10)
+		return len(deliveries) == 1
+	})
+	if dead := p.broker.Messages(p.cfg.KafkaEmailDLQTopic); len(dead) != 0 {
+		t.Errorf("dead-lettered %d messages, want none", len(dead))
+	}
+	if pending := p.outbox.pending(); pending != 0 {
+		t.Errorf("%d outbox messages left unsent", pending)
+	}
+}
+
+func TestEmailDeadLetteredAfterMaxAttempts(t *testing.T) {
+	transport := &failingTransport{err: textproto.ProtocolError("connection reset")}
+	p := startPipeline(t, transport)
+
+	if err := p.emails.SendVerificationEmail(context.Background(), "jane@example.com", "", "token"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the email to be dead-lettered", func() bool {
+		return len(p.broker.Messages(p.cfg.KafkaEmailDLQTopic)) > 0
+	})
+
+	if attempts := transport.attempts(); attempts != p.cfg.EmailMaxAttempts {
+		t.Errorf("made %d attempts, want EMAIL_MAX_ATTEMPTS = %d", attempts, p.cfg.EmailMaxAttempts)
+	}
+	assertDeadLettered(t, p, 3, "connection reset")
+}
+
+func TestEmailDeadLetteredOnPermanentFailure(t *testing.T) {
+	transport := &failingTransport{err: &textproto.Error{Code: 550, Msg: "mailbox unavailable"}}
+	p := startPipeline(t, transport)
+
+	if err := p.emails.SendVerificationEmail(context.Background(), "nobody@example.com", "", "token"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the email to be dead-lettered", func() bool {
+		return len(p.broker.Messages(p.cfg.KafkaEmailDLQTopic)) > 0
+	})
+
+	if attempts := transport.attempts(); attempts != 1 {
+		t.Errorf("made %d attempts, want 1", attempts)
+	}
+	assertDeadLettered(t, p, 1, "mailbox unavailable")
+}
+
+func assertDeadLettered(t *testing.T, p *pipeline, attempts int, cause string) {
+	t.Helper()
+	dead := p.broker.Messages(p.cfg.KafkaEmailDLQTopic)
+	if len(dead) != 1 {
+		t.Fatalf("dead-lettered %d messages, want 1", len(dead))
+	}
+	original := p.broker.Messages(p.cfg.KafkaEmailTopic)
+	if len(original) != 1 {
+		t.Fatalf("published %d messages, want 1", len(original))
+	}
+	if string(dead[0].Value) != string(original[0].Value) {
+		t.Errorf("dead letter value = %s, want the original %s", dead[0].Value, original[0].Value)
+	}
+	headers := dead[0].Headers
+	if headers[messaging.HeaderAttempts] != strconv.Itoa(attempts) {
+		t.Errorf("%s = %q, want %d", messaging.HeaderAttempts, headers[messaging.HeaderAttempts], attempts)
+	}
+	if headers[messaging.HeaderOriginalTopic] != p.cfg.KafkaEmailTopic {
+		t.Errorf("%s = %q, want %q", messaging.HeaderOriginalTopic, headers[messaging.HeaderOriginalTopic], p.cfg.KafkaEmailTopic)
+	}
+	if !strings.Contains(headers[messaging.HeaderError], cause) {
+		t.Errorf("%s = %q, want it to mention %q", messaging.HeaderError, headers[messaging.HeaderError], cause)
+	}
+
+	var deliveries []*models.EmailDelivery
+	waitFor(t, "the delivery to be recorded", func() bool {
+		deliveries, _, _ = p.deliveries.Find(context.Background(), domain.EmailDeliveryFilter{Status: models.EmailDeliveryDeadLettered}, 1, 10)
+		return len(deliveries) == 1
+	})
+	if deliveries[0].Attempts != attempts {
+		t.Errorf("delivery attempts = %d, want %d", deliveries[0].Attempts, attempts)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type failingTransport struct {
+	err error
+
+	mu    sync.Mutex
+	count int
+}
+
+func (t *failingTransport) Send(ctx context.Context, msg *mail.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count++
+	return t.err
+}
+
+func (t *failingTransport) attempts() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count
+}
+
+// memoryOutbox is a domain.OutboxRepository that drops the payload of sent
+// messages, like the Mongo one.
+type memoryOutbox struct {
+	mu       sync.Mutex
+	messages []*models.OutboxMessage
+}
+
+func (o *memoryOutbox) Add(ctx context.Context, msg *models.OutboxMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	msg.ID = primitive.NewObjectID()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+func (o *memoryOutbox) ClaimNext(ctx context.Context, lease time.Duration) (*models.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	for _, msg := range o.messages {
+		if msg.SentAt == nil && !msg.LockedUntil.After(now) {
+			msg.LockedUntil = now.Add(lease)
+			claimed := *msg
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (o *memoryOutbox) MarkSent(ctx context.Context, msg *models.OutboxMessage) error {
+	return o.update(msg, func(stored *models.OutboxMessage) {
+		now := time.Now()
+		stored.SentAt = &now
+		stored.Payload = ""
+	})
+}
+
+func (o *memoryOutbox) MarkFailed(ctx context.Context, msg *models.OutboxMessage, cause error) error {
+	return o.update(msg, func(stored *models.OutboxMessage) {
+		stored.Attempts++
+		stored.LastError = cause.Error()
+		stored.LockedUntil = time.Time{}
+	})
+}
+
+// The email pipeline does not look messages up by recipient
+func (o *memoryOutbox) FindPendingEmails(ctx context.Context, to string) ([]*models.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (o *memoryOutbox) DeletePendingEmails(ctx context.Context, to string) error {
+	return nil
+}
+
+func (o *memoryOutbox) update(msg *models.OutboxMessage, change func(*models.OutboxMessage)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, stored := range o.messages {
+		if stored.ID == msg.ID {
+			change(stored)
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	count := 0
+	for _, msg := range o.messages {
+		if msg.SentAt == nil || msg.Payload != "" {
+			count++
+		}
+	}
+	return count
+}
+
+type memoryDeliveries struct {
+	mu   sync.Mutex
+	byID map[string]*models.EmailDelivery
+}
+
+func (d *memoryDeliveries) FindByMessageID(ctx context.Context, messageID string) (*models.EmailDelivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if delivery, ok := d.byID[messageID]; ok {
+		copied := *delivery
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (d *memoryDeliveries) Save(ctx context.Context, delivery *models.EmailDelivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copied := *delivery
+	d.byID[delivery.MessageID] = &copied
+	return nil
+}
+
+func (d *memoryDeliveries) Find(ctx context.Context, filter domain.EmailDeliveryFilter, page, limit int) ([]*models.EmailDelivery, int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var found []*models.EmailDelivery
+	for _, delivery := range d.byID {
+		if filter.Status == "" || delivery.Status == filter.Status {
+			copied := *delivery
+			found = append(found, &copied)
+		}
+	}
+	return found, int64(len(found)), nil
+}
+
+func (d *memoryDeliveries) DeleteByRecipient(ctx context.Context, to string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, delivery := range d.byID {
+		if delivery.To == to {
+			delete(d.byID, id)
+		}
+	}
+	return nil
+}
@@ -2,110 +2,236 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"flag"
 	"inventory-service/infrastructure/cache"
 	"inventory-service/infrastructure/config"
 	"inventory-service/infrastructure/db"
+	"inventory-service/infrastructure/health"
 	"inventory-service/infrastructure/http/routes"
+	"inventory-service/infrastructure/logging"
 	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/metrics"
+	"inventory-service/infrastructure/repository"
 	"inventory-service/infrastructure/services"
+	"inventory-service/infrastructure/tracing"
 	"log"
+	"log/slog"
 	"net/http"
-	"net/smtp"
-
-	"github.com/IBM/sarama"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(os.Args[1:])
+	if errors.Is(err, config.ErrPrintConfig) || errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
-	
-	fmt.Println(cfg.MongoURL)
+
+	// Log JSON lines, or text for reading in a terminal; the standard log package,
+	// used by some libraries, goes through the same logger
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	// Fail on a broken message schema now rather than on the first message
+	if err := messaging.CompileSchemas(); err != nil {
+		fatal("Failed to load message schemas", err)
+	}
+
+	// Trace requests and messages; the spans still buffered are flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), "inventory-service", cfg.TracingExporter, cfg.TracingOTLPEndpoint)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
 	// Initialize MongoDB
 	mongoClient, err := db.NewMongoClient(cfg.MongoURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		fatal("Failed to connect to MongoDB", err)
 	}
 	defer mongoClient.Disconnect()
 
 	// Initialize Redis
 	redisClient, err := cache.NewRedisClient(cfg.RedisURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		fatal("Failed to connect to Redis", err)
 	}
 	defer redisClient.Disconnect()
 
 	// Initialize Kafka Producer
-	kafkaProducer := messaging.NewKafkaProducer(cfg.KafkaBroker, cfg.KafkaEmailTopic)
+	kafkaProducer, err := messaging.NewKafkaProducer(cfg.KafkaBrokers)
+	if err != nil {
+		fatal("Failed to create Kafka producer", err)
+	}
 	defer kafkaProducer.Close()
 
-	// Start Kafka Consumer in a goroutine
-	go startKafkaEmailConsumer(cfg)
+	// Stop on SIGINT or SIGTERM, e.g. from Kubernetes when the pod is deleted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Register with Eureka Server
-	services.RegisterWithEureka()
-	go services.SendHeartbeat()
-
-	// Setup and start HTTP server using routes.SetupRouter
-	router := routes.SetupRouter(mongoClient, cfg, kafkaProducer, redisClient) // Pass redisClient
-	log.Printf("Server starting on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, router))
-
-}
-
-// Kafka Email Consumer Logic
-type emailConsumerHandler struct {
-	cfg *config.Config
-}
+	// Bring existing data into the shape the code expects before anything uses it
+	if err := repository.Migrate(ctx, mongoClient, "inventory_db"); err != nil {
+		fatal("Failed to migrate the database", err)
+	}
 
-func (h *emailConsumerHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
-func (h *emailConsumerHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+	// Built once, shared by the HTTP routes and the workers below
+	deps := routes.NewDependencies(mongoClient, cfg, redisClient)
+
+	// Publish the messages usecases write to the outbox; emails queued on Kafka are
+	// delivered by the separate email-worker command. The relay is stopped last, so
+	// that it publishes what the requests and consumers write while draining
+	relay := startWorker(func(ctx context.Context) {
+		messaging.NewOutboxRelay(deps.OutboxRepo, kafkaProducer, time.Second).Run(ctx)
+	})
+
+	// Reserve, sell and return stock as orders move through their lifecycle. The
+	// consumer is restarted until shutdown, as stock would otherwise stop following
+	// orders while the service still looks healthy
+	orderConsumer := messaging.NewOrderEventConsumer(deps.StockUsecase.HandleOrderEvent, kafkaProducer, cfg.KafkaOrderEventsDLQTopic, messaging.RetryPolicy{Backoff: time.Second, MaxBackoff: time.Minute})
+	consumers := startWorker(func(ctx context.Context) {
+		backoff := time.Second
+		for {
+			err := orderConsumer.Run(ctx, messaging.NewKafkaSubscriber(cfg.KafkaBrokers), "inventory-order-events", cfg.KafkaOrderEventsTopic)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.ErrorContext(ctx, "Order event consumer stopped, restarting", "error", err, "backoff", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, time.Minute)
+		}
+	})
+
+	// Computed on each scrape of /metrics
+	metrics.RegisterStockCollector(deps.StockRepo.Summary, cfg.HealthCheckTimeout)
+
+	// The service is ready for traffic while MongoDB, Redis and Kafka all answer
+	checker := health.NewChecker(cfg.HealthCheckTimeout,
+		health.Check{Name: "mongo", Ping: func(ctx context.Context) error { return mongoClient.Ping(ctx, nil) }},
+		health.Check{Name: "redis", Ping: func(ctx context.Context) error { return redisClient.Ping(ctx).Err() }},
+		health.Check{Name: "kafka", Ping: kafkaProducer.Ping},
+	)
+
+	// Register with Eureka Server, then keep its status in line with readiness
+	eureka := services.NewEurekaClient(cfg)
+	if err := eureka.Register(ctx); err != nil {
+		slog.WarnContext(ctx, "Failed to register with Eureka, retrying with the heartbeats", "error", err)
+	}
+	heartbeat := startWorker(eureka.Run)
+	readiness := startWorker(func(ctx context.Context) {
+		checker.Watch(ctx, cfg.HealthCheckInterval, func(ctx context.Context, report health.Report) error {
+			if report.Status == health.StatusUp {
+				return eureka.SetStatus(ctx, services.StatusUp)
+			}
+			return eureka.SetStatus(ctx, services.StatusOutOfService)
+		})
+	})
 
-func (h *emailConsumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		var emailMsg messaging.EmailMessage
-		if err := json.Unmarshal(msg.Value, &emailMsg); err != nil {
-			log.Printf("Failed to unmarshal message: %v", err)
-			continue
+	// Setup and start HTTP server using routes.SetupRouter
+	router := routes.SetupRouter(deps, cfg, checker)
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Port)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
 		}
+	}()
 
-		auth := smtp.PlainAuth("", h.cfg.SMTPUsername, h.cfg.SMTPPassword, h.cfg.SMTPHost)
-		smtpMsg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", emailMsg.To, emailMsg.Subject, emailMsg.Body))
-		addr := fmt.Sprintf("%s:%d", h.cfg.SMTPHost, h.cfg.SMTPPort)
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case err := <-serverErr:
+		slog.Error("Server failed, shutting down", "error", err)
+	}
+	stop() // A second signal kills the process at once
+
+	// Everything below shares the shutdown timeout; what is left when it runs out is
+	// abandoned, and the deferred clients are closed regardless
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Take the instance out of rotation first: fail readiness for Kubernetes, and leave
+	// Eureka once nothing can register it again, then give callers time to notice
+	checker.Drain()
+	if err := readiness.stop(shutdownCtx); err != nil {
+		slog.Error("Failed to stop the readiness watch", "error", err)
+	}
+	if err := heartbeat.stop(shutdownCtx); err != nil {
+		slog.Error("Failed to stop the Eureka heartbeat", "error", err)
+	}
+	if err := eureka.Deregister(shutdownCtx); err != nil {
+		slog.Error("Failed to deregister from Eureka", "error", err)
+	}
+	select {
+	case <-time.After(cfg.ShutdownDelay):
+	case <-shutdownCtx.Done():
+	}
 
-		if err := smtp.SendMail(addr, auth, h.cfg.EmailFrom, []string{emailMsg.To}, smtpMsg); err != nil {
-			log.Printf("Failed to send email to %s: %v", emailMsg.To, err)
-		} else {
-			log.Printf("Email sent to %s", emailMsg.To)
-		}
-		session.MarkMessage(msg, "")
+	// Stop accepting connections and wait for the requests in flight
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to drain HTTP requests", "error", err)
 	}
-	return nil
+	// Consumers commit the offsets of the messages they handled before returning
+	if err := consumers.stop(shutdownCtx); err != nil {
+		slog.Error("Failed to stop consumers", "error", err)
+	}
+	if err := relay.stop(shutdownCtx); err != nil {
+		slog.Error("Failed to stop the outbox relay", "error", err)
+	}
+
+	// The deferred calls close Kafka, then Redis, then MongoDB
+	slog.Info("Shutdown complete")
 }
 
-func startKafkaEmailConsumer(cfg *config.Config) {
-	config := sarama.NewConfig()
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+// fatal logs err and exits; deferred calls do not run.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
 
-	consumerGroup, err := sarama.NewConsumerGroup([]string{cfg.KafkaBroker}, "email-consumer-group", config)
-	if err != nil {
-		log.Fatalf("Failed to create consumer group: %v", err)
-	}
-	defer consumerGroup.Close()
+// worker is a background task that runs until stopped.
+type worker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
 
-	handler := &emailConsumerHandler{cfg: cfg}
-	ctx := context.Background()
+func startWorker(run func(ctx context.Context)) *worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+	return w
+}
 
-	for {
-		err := consumerGroup.Consume(ctx, []string{cfg.KafkaEmailTopic}, handler)
-		if err != nil {
-			log.Printf("Error consuming messages: %v", err)
-		}
+// stop cancels the worker and waits for it to return, or for ctx to be done.
+func (w *worker) stop(ctx context.Context) error {
+	w.cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"inventory-service/domain/models"
+	"time"
+)
+
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditRepository is append-only: entries can be added and queried but never changed,
+// except to erase the personal data of a deleted user.
+type AuditRepository interface {
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	Find(ctx context.Context, filter AuditFilter, page, limit int) ([]*models.AuditEntry, int64, error)
+	// FindByUser returns the entries the user performed or that target them, newest first
+	FindByUser(ctx context.Context, userID string) ([]*models.AuditEntry, error)
+	// AnonymizeUser clears the client IP of the entries the user performed and the
+	// snapshots of those that target them
+	AnonymizeUser(ctx context.Context, userID string) error
+}
@@ -1,11 +1,18 @@
 package domain
 
-import "inventory-service/domain/models"
+import (
+	"context"
+	"inventory-service/domain/models"
+)
+
+// ErrCategoryNotFound is returned for a category that does not exist, except by FindByID,
+// which returns nil.
+var ErrCategoryNotFound = NewNotFoundError("category not found")
 
 type CategoryRepository interface {
-	Create(category *models.Category) error
-	Update(category *models.Category) error
-	Delete(id string) error
-	FindByID(id string) (*models.Category, error)
-	FindAll() ([]*models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*models.Category, error)
+	FindAll(ctx context.Context) ([]*models.Category, error)
 }
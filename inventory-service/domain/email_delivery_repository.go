@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+	"inventory-service/domain/models"
+)
+
+type EmailDeliveryFilter struct {
+	Status string
+	To     string
+	Type   string
+}
+
+type EmailDeliveryRepository interface {
+	FindByMessageID(ctx context.Context, messageID string) (*models.EmailDelivery, error)
+	// Save inserts or replaces the delivery with the same message ID
+	Save(ctx context.Context, delivery *models.EmailDelivery) error
+	Find(ctx context.Context, filter EmailDeliveryFilter, page, limit int) ([]*models.EmailDelivery, int64, error)
+	DeleteByRecipient(ctx context.Context, to string) error
+}
@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"context"
+	"inventory-service/domain/models"
+)
+
+type EmailTemplateRepository interface {
+	Find(ctx context.Context, name, locale string) (*models.EmailTemplate, error)
+	Upsert(ctx context.Context, tmpl *models.EmailTemplate) error
+	Delete(ctx context.Context, name, locale string) error
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kinds of errors reported by repositories and usecases, matched with errors.Is. The
+// HTTP layer maps each kind to a status; any other error is an internal one, whose
+// message is never shown to clients.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("too many requests")
+)
+
+// Error is an error of one of the kinds above. Its message is meant for clients, so
+// it must not carry internal details such as database errors.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Is(target error) bool { return target == e.Kind }
+
+func NewNotFoundError(message string) *Error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func NewConflictError(message string) *Error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func NewForbiddenError(message string) *Error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func NewUnauthorizedError(message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Message: message}
+}
+
+func NewRateLimitedError(message string) *Error {
+	return &Error{Kind: ErrRateLimited, Message: message}
+}
+
+// FieldError is the problem with one field of a request, named by its path in the
+// request body, e.g. products[0].quantity.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports a request that is invalid as a whole or in some of its
+// fields.
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
+func NewValidationError(message string, fields ...FieldError) *ValidationError {
+	return &ValidationError{Message: message, Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	problems := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		problems[i] = f.Field + " " + f.Message
+	}
+	return e.Message + ": " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"inventory-service/domain/models"
+	"time"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) error
+	Update(ctx context.Context, invitation *models.Invitation) error
+	FindByID(ctx context.Context, id string) (*models.Invitation, error)
+	FindByTokenHash(ctx context.Context, hash string) (*models.Invitation, error)
+	FindPendingByEmail(ctx context.Context, email string) (*models.Invitation, error)
+	// MarkAccepted sets the acceptance time of the invitation if it is still pending,
+	// and reports whether it was
+	MarkAccepted(ctx context.Context, id string, at time.Time) (bool, error)
+	FindAll(ctx context.Context, status string, page, limit int) ([]*models.Invitation, int64, error) // status is one of the models.Invitation* constants, or empty for all
+	// FindByUser returns the invitations sent to email or by the user
+	FindByUser(ctx context.Context, userID, email string) ([]*models.Invitation, error)
+	// EraseUser deletes the invitations sent to email and clears the inviter of those
+	// sent by the user
+	EraseUser(ctx context.Context, userID, email string) error
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+// Message is a message published to, or consumed from, a topic of the message broker.
+type Message struct {
+	Topic   string
+	Key     []byte // Messages with the same key are delivered in the order published
+	Value   []byte
+	Headers map[string]string
+	// Partition and Offset locate a consumed message within its topic
+	Partition int32
+	Offset    int64
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// MessageHandler handles one consumed message. An error leaves the message
+// unacknowledged, so it is delivered again.
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+type Subscriber interface {
+	// Subscribe consumes topic as a member of group until ctx is cancelled. Each
+	// message is handled by one member of each group, and messages with the same key
+	// are handled one at a time in the order published.
+	Subscribe(ctx context.Context, group, topic string, handler MessageHandler) error
+}
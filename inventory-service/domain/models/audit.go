@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records a mutating action: who did it, to what, and what changed.
+type AuditEntry struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OccurredAt time.Time          `json:"occurred_at" bson:"occurred_at"`
+	ActorID    string             `json:"actor_id" bson:"actor_id"`
+	ActorRole  string             `json:"actor_role" bson:"actor_role"`
+	Action     string             `json:"action" bson:"action"` // e.g. "product.delete", "user.update"
+	TargetType string             `json:"target_type" bson:"target_type"`
+	TargetID   string             `json:"target_id,omitempty" bson:"target_id,omitempty"`
+	Before     interface{}        `json:"before,omitempty" bson:"before,omitempty"`
+	After      interface{}        `json:"after,omitempty" bson:"after,omitempty"`
+	IP         string             `json:"ip" bson:"ip"`
+	RequestID  string             `json:"request_id" bson:"request_id"`
+	Method     string             `json:"method" bson:"method"`
+	Path       string             `json:"path" bson:"path"`
+	Status     int                `json:"status" bson:"status"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EmailDeliveryRetrying     = "retrying"
+	EmailDeliverySent         = "sent"
+	EmailDeliveryDeadLettered = "dead_lettered"
+)
+
+// EmailDelivery tracks the delivery of one queued email across attempts.
+type EmailDelivery struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	MessageID string             `json:"message_id" bson:"message_id"`
+	Type      string             `json:"type" bson:"type"`
+	To        string             `json:"to" bson:"to"`
+	Subject   string             `json:"subject" bson:"subject"`
+	Status    string             `json:"status" bson:"status"`
+	Attempts  int                `json:"attempts" bson:"attempts"`
+	LastError string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	SentAt    *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+}
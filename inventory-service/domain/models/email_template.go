@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailTemplate overrides a built-in email template for one locale; an empty
+// locale overrides the default variant.
+type EmailTemplate struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Locale    string             `json:"locale" bson:"locale"`
+	Source    string             `json:"source" bson:"source"`
+	UpdatedBy string             `json:"updated_by" bson:"updated_by"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxMessage is a Kafka message written in the same transaction as the change it
+// announces, and published afterwards by the outbox relay.
+type OutboxMessage struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Topic       string             `json:"topic" bson:"topic"`
+	Key         string             `json:"key,omitempty" bson:"key,omitempty"`
+	Payload     string             `json:"payload" bson:"payload"` // JSON-encoded message value
+	Headers     map[string]string  `json:"headers,omitempty" bson:"headers,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	SentAt      *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	LastError   string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LockedUntil time.Time          `json:"-" bson:"locked_until"` // Lease held by the relay publishing it
+}
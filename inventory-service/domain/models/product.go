@@ -10,4 +10,7 @@ type Product struct {
 	Stock       int                `json:"stock" bson:"stock"`
 	ImageURL    string             `json:"image_url" bson:"image_url"`
 	Category    string             `json:"category" bson:"category"`
+	// Reserved is the stock held for orders awaiting payment, already taken out of
+	// Stock. Omitted when zero so that a full product update never overwrites it.
+	Reserved int `json:"reserved" bson:"reserved,omitempty"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReservationReserved  = "reserved"  // Stock held for an order awaiting payment
+	ReservationCommitted = "committed" // Order paid; the held stock has been sold
+	ReservationReleased  = "released"  // Order cancelled before payment; stock returned
+	ReservationRestored  = "restored"  // Order refunded after payment; stock returned
+	ReservationRejected  = "rejected"  // Not enough stock when the order was placed
+)
+
+type ReservationItem struct {
+	ProductID string `json:"product_id" bson:"product_id"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+}
+
+// StockReservation tracks the stock held for one order through its lifecycle.
+type StockReservation struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID   string             `json:"order_id" bson:"order_id"`
+	Items     []ReservationItem  `json:"items" bson:"items"`
+	Status    string             `json:"status" bson:"status"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -1,13 +1,46 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type User struct {
-	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Email             string             `json:"email" bson:"email"`
-	Password          string             `json:"password" bson:"password"`
-	Role              string             `json:"role" bson:"role"`
-	IsVerified        bool               `json:"is_verified" bson:"is_verified"`
-	VerificationToken string             `json:"-" bson:"verification_token"`
-	ResetToken        string             `json:"-" bson:"reset_token"`
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email         string             `json:"email" bson:"email"`
+	Name          string             `json:"name" bson:"name"`
+	Password      string             `json:"password" bson:"password"`
+	Role          string             `json:"role" bson:"role"`
+	Locale        string             `json:"locale,omitempty" bson:"locale,omitempty"` // Language of the emails sent to the user
+	IsVerified    bool               `json:"is_verified" bson:"is_verified"`
+	Verification  *OneTimeToken      `json:"-" bson:"verification"`
+	PasswordReset *OneTimeToken      `json:"-" bson:"password_reset"`
+	PendingEmail  string             `json:"-" bson:"pending_email"` // New address awaiting confirmation
+	EmailChange   *OneTimeToken      `json:"-" bson:"email_change"`
+	TokenVersion  int                `json:"-" bson:"token_version"` // Bumped to invalidate every issued JWT
+	MFA           MFASettings        `json:"-" bson:"mfa"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	LastLoginAt   *time.Time         `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+}
+
+// MFASettings holds the user's TOTP second factor.
+type MFASettings struct {
+	Enabled            bool     `bson:"enabled"`
+	Secret             string   `bson:"secret"`
+	PendingSecret      string   `bson:"pending_secret"` // Set during enrolment until the first code is confirmed
+	LastUsedStep       int64    `bson:"last_used_step"` // Prevents replaying a code within its validity window
+	RecoveryCodeHashes []string `bson:"recovery_code_hashes"`
+}
+
+// OneTimeToken is the stored form of a token sent to the user by email.
+// Only the SHA-256 hash is persisted, never the token itself.
+type OneTimeToken struct {
+	Hash      string    `bson:"hash"`
+	IssuedAt  time.Time `bson:"issued_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func (t *OneTimeToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
 }
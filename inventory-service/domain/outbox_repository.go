@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"inventory-service/domain/models"
+	"time"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, msg *models.OutboxMessage) error
+	// ClaimNext leases the oldest unsent message for the given duration so that no
+	// other relay publishes it meanwhile; it returns nil when there is none.
+	ClaimNext(ctx context.Context, lease time.Duration) (*models.OutboxMessage, error)
+	MarkSent(ctx context.Context, msg *models.OutboxMessage) error
+	// MarkFailed records the error and releases the lease so the message is retried first
+	MarkFailed(ctx context.Context, msg *models.OutboxMessage, cause error) error
+	// FindPendingEmails returns the unsent emails to an address; sent messages no
+	// longer hold their payload
+	FindPendingEmails(ctx context.Context, to string) ([]*models.OutboxMessage, error)
+	DeletePendingEmails(ctx context.Context, to string) error
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// ProcessedEventRepository remembers the consumed events that have been applied, so
+// that a redelivered event can be recognised and skipped.
+type ProcessedEventRepository interface {
+	Exists(ctx context.Context, eventID string) (bool, error)
+	Add(ctx context.Context, eventID, eventType string) error
+}
@@ -17,10 +17,14 @@ type ProductSort struct {
 	Order int    // 1 for ascending, -1 for descending
 }
 
+// ErrProductNotFound is returned for a product that does not exist, except by FindByID,
+// which returns nil.
+var ErrProductNotFound = NewNotFoundError("product not found")
+
 type ProductRepository interface {
-	Create(product *models.Product) error
-	Update(product *models.Product) error
-	Delete(id string) error
-	FindByID(id string) (*models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	Update(ctx context.Context, product *models.Product) error
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*models.Product, error)
 	FindAll(ctx context.Context, filter ProductFilter, sort ProductSort, page, limit int) ([]*models.Product, int64, error) // Updated with filtering, sorting, paging
 }
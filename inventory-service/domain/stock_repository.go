@@ -3,8 +3,20 @@ package domain
 import "context"
 
 type StockRepository interface {
+	// BulkUpdateStock applies the updates and returns the resulting stock level of
+	// each product updated; unknown products are left out.
 	BulkUpdateStock(ctx context.Context, updates map[string]struct {
 		Quantity  int
 		Increment bool
-	}) error
+	}) (map[string]int, error)
+	// ReserveStock moves quantity from the product's stock to its reserved stock and
+	// returns the new stock level. ok is false, and nothing changes, when the product
+	// does not exist or has less than quantity in stock.
+	ReserveStock(ctx context.Context, productID string, quantity int) (stock int, ok bool, err error)
+	// AdjustStock adds the deltas to the product's stock and reserved stock and
+	// returns the new stock level. ok is false when the product does not exist.
+	AdjustStock(ctx context.Context, productID string, stockDelta, reservedDelta int) (stock int, ok bool, err error)
+	// Summary returns the units in stock over all products and the number of
+	// products with none.
+	Summary(ctx context.Context) (units, outOfStock int, err error)
 }
@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"context"
+	"inventory-service/domain/models"
+)
+
+type StockReservationRepository interface {
+	FindByOrderID(ctx context.Context, orderID string) (*models.StockReservation, error)
+	// Save inserts or replaces the reservation with the same order ID
+	Save(ctx context.Context, reservation *models.StockReservation) error
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// Transactor runs fn atomically: the repository calls fn makes with the context it
+// is given either all take effect or none do.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -3,11 +3,28 @@ package domain
 import (
 	"context"
 	"inventory-service/domain/models"
+	"time"
 )
 
+type UserFilter struct {
+	Email       string // Case-insensitive substring
+	Role        string
+	IsVerified  *bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+type UserSort struct {
+	Field string // e.g., "email", "role", "created_at", "last_login_at"
+	Order int    // 1 for ascending, -1 for descending
+}
+
+// ErrUserNotFound is returned by UserInfoRepository for a user that does not exist.
+var ErrUserNotFound = NewNotFoundError("user not found")
+
 type UserInfoRepository interface {
 	GetByID(ctx context.Context, id string) (*models.User, error)
-	GetAll(ctx context.Context) ([]*models.User, error)
+	GetAll(ctx context.Context, filter UserFilter, sort UserSort, page, limit int) ([]*models.User, int64, error)
 	Update(ctx context.Context, id string, user *models.User) (*models.User, error)
 	Delete(ctx context.Context, id string) error
 }
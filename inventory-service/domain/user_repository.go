@@ -1,12 +1,17 @@
 package domain
 
-import "inventory-service/domain/models"
+import (
+	"context"
+	"inventory-service/domain/models"
+)
 
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByEmail(email string) (*models.User, error)
-	FindByID(id string) (*models.User, error)
-	Update(user *models.User) error
-	FindByVerificationToken(token string) (*models.User, error)
-	FindByResetToken(token string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id string) error
+	FindByVerificationTokenHash(ctx context.Context, hash string) (*models.User, error)
+	FindByResetTokenHash(ctx context.Context, hash string) (*models.User, error)
+	FindByEmailChangeTokenHash(ctx context.Context, hash string) (*models.User, error)
 }
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type draftKey struct{}
+
+// Draft collects the details of an audited request that only the handler knows,
+// such as the ID of a newly created resource and the before/after snapshots.
+type Draft struct {
+	TargetID string
+	Before   interface{}
+	After    interface{}
+}
+
+// WithDraft returns a context carrying a new, empty draft.
+func WithDraft(ctx context.Context) (context.Context, *Draft) {
+	draft := &Draft{}
+	return context.WithValue(ctx, draftKey{}, draft), draft
+}
+
+func draftFrom(ctx context.Context) *Draft {
+	draft, _ := ctx.Value(draftKey{}).(*Draft)
+	return draft
+}
+
+// SetTargetID records the ID of the resource the request acted on.
+func SetTargetID(ctx context.Context, id string) {
+	if draft := draftFrom(ctx); draft != nil {
+		draft.TargetID = id
+	}
+}
+
+// SetBefore records the state of the target before the change.
+func SetBefore(ctx context.Context, v interface{}) {
+	if draft := draftFrom(ctx); draft != nil {
+		draft.Before = Snapshot(v)
+	}
+}
+
+// SetAfter records the state of the target after the change.
+func SetAfter(ctx context.Context, v interface{}) {
+	if draft := draftFrom(ctx); draft != nil {
+		draft.After = Snapshot(v)
+	}
+}
+
+// Snapshot converts v to its JSON shape so the stored snapshot uses the same field
+// names as the API and is detached from later changes to v.
+func Snapshot(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var snapshot interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"inventory-service/infrastructure/tracing"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -17,6 +18,7 @@ func NewRedisClient(url string) (*RedisClient, error) {
 		return nil, err
 	}
 	client := redis.NewClient(opt)
+	client.AddHook(tracing.RedisHook())
 	_, err = client.Ping(context.Background()).Result()
 	if err != nil {
 		return nil, err
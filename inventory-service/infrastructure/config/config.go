@@ -1,52 +1,273 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"inventory-service/utils"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// EmailLinks holds the URL pattern of each link type sent by email. Patterns may use
+// {ui} and {api} for the public base URLs and must contain {token}.
+type EmailLinks struct {
+	Verification  string `config:"EMAIL_LINK_VERIFICATION" default:"{api}/users/verify/{token}"`
+	PasswordReset string `config:"EMAIL_LINK_PASSWORD_RESET" default:"{ui}/reset-password/{token}"`
+	EmailChange   string `config:"EMAIL_LINK_EMAIL_CHANGE" default:"{api}/users/email/confirm/{token}"`
+	Invitation    string `config:"EMAIL_LINK_INVITATION" default:"{ui}/invitations/accept/{token}"`
+}
+
+// Config is the service configuration. Each setting is named by its environment
+// variable in the config tag; the same name in lower case is its key in the YAML
+// file, and in lower case with dashes its command-line flag, e.g. MONGO_URL,
+// mongo_url and --mongo-url. Settings tagged secret are redacted when printed and
+// may also be read from a file named by the _FILE variant, e.g. MONGO_URL_FILE.
 type Config struct {
-	Port                string
-	MongoURL            string
-	CloudinaryCloudName string
-	CloudinaryAPIKey    string
-	CloudinaryAPISecret string
-	EmailFrom           string
-	SMTPHost            string
-	SMTPPort            int
-	SMTPUsername        string
-	SMTPPassword        string
-	ServiceAPIKey       string
-	RedisURL            string
-	KafkaBroker         string
-	KafkaEmailTopic     string
-}
-
-func LoadConfig() (*Config, error) {
-	cfg := &Config{
-		Port:                os.Getenv("PORT"),
-		MongoURL:            os.Getenv("MONGO_URL"),
-		CloudinaryCloudName: os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		CloudinaryAPIKey:    os.Getenv("CLOUDINARY_API_KEY"),
-		CloudinaryAPISecret: os.Getenv("CLOUDINARY_API_SECRET"),
-		EmailFrom:           os.Getenv("EMAIL_FROM"),
-		SMTPHost:            os.Getenv("SMTP_HOST"),
-		SMTPUsername:        os.Getenv("SMTP_USERNAME"),
-		SMTPPassword:        os.Getenv("SMTP_PASSWORD"),
-		ServiceAPIKey:       os.Getenv("SERVICE_API_KEY"),
-		RedisURL:            os.Getenv("REDIS_URL"),
-		KafkaBroker:         os.Getenv("KAFKA_BROKER"),
-		KafkaEmailTopic:     os.Getenv("KAFKA_EMAIL_TOPIC"),
-	}
-
-	// Parse SMTP_PORT from string to int
-	if smtpPortStr := os.Getenv("SMTP_PORT"); smtpPortStr != "" {
-		if port, err := strconv.Atoi(smtpPortStr); err == nil {
-			cfg.SMTPPort = port
-		} else {
-			return nil, err
+	Port                        string   `config:"PORT" default:"8080"`
+	MongoURL                    string   `config:"MONGO_URL" required:"true" secret:"true"`
+	CloudinaryCloudName         string   `config:"CLOUDINARY_CLOUD_NAME"`
+	CloudinaryAPIKey            string   `config:"CLOUDINARY_API_KEY"`
+	CloudinaryAPISecret         string   `config:"CLOUDINARY_API_SECRET" secret:"true"`
+	EmailFrom                   string   `config:"EMAIL_FROM"`
+	SMTPHost                    string   `config:"SMTP_HOST"`
+	SMTPPort                    int      `config:"SMTP_PORT" default:"587" min:"1"`
+	SMTPUsername                string   `config:"SMTP_USERNAME"`
+	SMTPPassword                string   `config:"SMTP_PASSWORD" secret:"true"`
+	SMTPSecurity                string   `config:"SMTP_SECURITY" default:"starttls" oneof:"none starttls tls"`
+	MailTransport               string   `config:"MAIL_TRANSPORT" default:"smtp" oneof:"smtp file memory"`
+	MailOutboxDir               string   `config:"MAIL_OUTBOX_DIR" default:"outbox"`
+	ServiceAPIKey               string   `config:"SERVICE_API_KEY" required:"true" secret:"true"`
+	RedisURL                    string   `config:"REDIS_URL" required:"true" secret:"true"`
+	KafkaBrokers                []string `config:"KAFKA_BROKER" required:"true"` // Comma-separated
+	KafkaEmailTopic             string   `config:"KAFKA_EMAIL_TOPIC" default:"email_notifications"`
+	KafkaEmailDLQTopic          string   `config:"KAFKA_EMAIL_DLQ_TOPIC"` // Defaults to the email topic + ".dlq"
+	KafkaAuditTopic             string   `config:"KAFKA_AUDIT_TOPIC"`     // Audit entries are not published when empty
+	KafkaInventoryEventsTopic   string   `config:"KAFKA_INVENTORY_EVENTS_TOPIC" default:"inventory_events"`
+	KafkaOrderEventsTopic       string   `config:"KAFKA_ORDER_EVENTS_TOPIC" default:"order_events"`
+	KafkaOrderEventsDLQTopic    string   `config:"KAFKA_ORDER_EVENTS_DLQ_TOPIC"` // Defaults to the order events topic + ".dlq"
+	MFAIssuer                   string   `config:"MFA_ISSUER" default:"Inventory Service"`
+	MFARequiredRoles            []string `config:"MFA_REQUIRED_ROLES"`
+	EmailTemplateDir            string   `config:"EMAIL_TEMPLATE_DIR"`
+	PublicUIURL                 string   `config:"PUBLIC_UI_URL"`  // Defaults to http://localhost:<port>
+	PublicAPIURL                string   `config:"PUBLIC_API_URL"` // Defaults to the UI URL + "/inventory/api"
+	EmailLinks                  EmailLinks
+	LowStockThreshold           int           `config:"LOW_STOCK_THRESHOLD" default:"5" min:"0"`
+	LowStockRecipients          []string      `config:"LOW_STOCK_ALERT_RECIPIENTS"`
+	EmailMaxAttempts            int           `config:"EMAIL_MAX_ATTEMPTS" default:"5" min:"1"`
+	EmailRetryBackoff           time.Duration `config:"EMAIL_RETRY_BACKOFF" default:"2s"`
+	EurekaServerURLs            []string      `config:"EUREKA_SERVER_URLS" default:"http://localhost:8761/eureka" required:"true"` // Tried in order, failing over to the next
+	EurekaAppName               string        `config:"EUREKA_APP_NAME" default:"INVENTORY-SERVICE" required:"true"`
+	EurekaInstanceID            string        `config:"EUREKA_INSTANCE_ID"` // Defaults to <host>:<app>:<port>
+	EurekaHostName              string        `config:"EUREKA_HOSTNAME"`    // Defaults to the host name, i.e. the pod name on Kubernetes
+	EurekaIPAddr                string        `config:"EUREKA_IP_ADDR"`     // Defaults to the first non-loopback IPv4 address
+	EurekaHeartbeatInterval     time.Duration `config:"EUREKA_HEARTBEAT_INTERVAL" default:"30s" min:"1"`
+	EurekaRegistryFetchInterval time.Duration `config:"EUREKA_REGISTRY_FETCH_INTERVAL" default:"30s" min:"1"`
+	LogLevel                    string        `config:"LOG_LEVEL" default:"info" oneof:"debug info warn error"`
+	LogFormat                   string        `config:"LOG_FORMAT" default:"json" oneof:"json text"`
+	TracingExporter             string        `config:"TRACING_EXPORTER" default:"none" oneof:"none stdout otlp"`
+	TracingOTLPEndpoint         string        `config:"TRACING_OTLP_ENDPOINT"`                 // e.g. http://otel-collector:4318; defaults to OTEL_EXPORTER_OTLP_ENDPOINT
+	HealthCheckTimeout          time.Duration `config:"HEALTH_CHECK_TIMEOUT" default:"2s"`     // Per dependency pinged by /health/ready
+	HealthCheckInterval         time.Duration `config:"HEALTH_CHECK_INTERVAL" default:"15s"`   // How often readiness is reported to Eureka
+	ShutdownTimeout             time.Duration `config:"SHUTDOWN_TIMEOUT" default:"20s"`        // Within the default 30s Kubernetes grace period
+	ShutdownDelay               time.Duration `config:"SHUTDOWN_DELAY" default:"5s"`           // Reported down before draining, for callers to notice; part of SHUTDOWN_TIMEOUT
+	RequestTimeout              time.Duration `config:"REQUEST_TIMEOUT" default:"30s" min:"1"` // For routes not listed in ROUTE_TIMEOUTS
+	RouteTimeouts               []string      `config:"ROUTE_TIMEOUTS"`                        // e.g. "POST /inventory/api/products=1m"; see ParseRouteTimeout
+	TrustedProxies              []string      `config:"TRUSTED_PROXIES"`                       // IPs or CIDR ranges, e.g. of the gateway, whose X-Forwarded-For is believed
+}
+
+// defaultConfigFile is read when present and no other file is named.
+const defaultConfigFile = "config.yaml"
+
+// ErrPrintConfig is returned by LoadConfig, after printing the effective
+// configuration, when run with --print-config.
+var ErrPrintConfig = errors.New("configuration printed")
+
+// ValidationError lists every problem found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// LoadConfig builds the configuration from, in increasing precedence, the defaults,
+// the YAML file, the environment and the command-line flags in args. The YAML file
+// is named by --config or CONFIG_FILE, or else is config.yaml if there is one. With
+// --print-config, the configuration is printed to stdout with secrets redacted and
+// ErrPrintConfig is returned.
+func LoadConfig(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := fieldsOf(cfg)
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML configuration file (default $CONFIG_FILE or "+defaultConfigFile+")")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flagValues := make(map[string]*string)
+	for _, f := range fields {
+		flagValues[f.flagName()] = flags.String(f.flagName(), "", "overrides "+f.name)
+		if f.secret() {
+			flagValues[f.flagName()+"-file"] = flags.String(f.flagName()+"-file", "", "reads "+f.name+" from a file")
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup("default"); ok {
+			if err := f.set(def); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid default: %v", f.name, err))
+			}
 		}
 	}
 
+	path, required := *configFile, true
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path, required = defaultConfigFile, false
+	}
+	problems = append(problems, applyFile(fields, path, required)...)
+	problems = append(problems, applyEnv(fields)...)
+
+	// Flags go last; only those given on the command line override anything
+	set := make(map[string]bool)
+	flags.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	for _, f := range fields {
+		if set[f.flagName()] {
+			problems = append(problems, f.apply("--"+f.flagName(), *flagValues[f.flagName()])...)
+		}
+		if set[f.flagName()+"-file"] {
+			problems = append(problems, f.applySecretFile("--"+f.flagName()+"-file", *flagValues[f.flagName()+"-file"])...)
+		}
+	}
+
+	cfg.deriveDefaults()
+	problems = append(problems, cfg.validate(fields)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	if *printConfig {
+		if err := Print(os.Stdout, cfg); err != nil {
+			return nil, err
+		}
+		return nil, ErrPrintConfig
+	}
 	return cfg, nil
 }
+
+func applyEnv(fields []field) []string {
+	var problems []string
+	for _, f := range fields {
+		if value, ok := os.LookupEnv(f.name); ok && value != "" {
+			problems = append(problems, f.apply(f.name, value)...)
+		}
+		if !f.secret() {
+			continue
+		}
+		if path := os.Getenv(f.name + "_FILE"); path != "" {
+			if os.Getenv(f.name) != "" {
+				problems = append(problems, fmt.Sprintf("%s and %s_FILE are both set", f.name, f.name))
+				continue
+			}
+			problems = append(problems, f.applySecretFile(f.name+"_FILE", path)...)
+		}
+	}
+	return problems
+}
+
+// deriveDefaults fills in the settings whose defaults depend on other settings.
+func (c *Config) deriveDefaults() {
+	// Links in emails must point at the public addresses, e.g. the cloud-gateway when
+	// running behind it; the defaults only work when the service is reached directly
+	if c.PublicUIURL == "" {
+		c.PublicUIURL = "http://localhost:" + c.Port
+	}
+	if c.PublicAPIURL == "" {
+		c.PublicAPIURL = c.PublicUIURL + "/inventory/api"
+	}
+	c.PublicUIURL = strings.TrimRight(c.PublicUIURL, "/")
+	c.PublicAPIURL = strings.TrimRight(c.PublicAPIURL, "/")
+
+	if c.KafkaEmailDLQTopic == "" {
+		c.KafkaEmailDLQTopic = c.KafkaEmailTopic + ".dlq"
+	}
+	if c.KafkaOrderEventsDLQTopic == "" {
+		c.KafkaOrderEventsDLQTopic = c.KafkaOrderEventsTopic + ".dlq"
+	}
+}
+
+func (c *Config) validate(fields []field) []string {
+	var problems []string
+	for _, f := range fields {
+		problems = append(problems, f.validate()...)
+	}
+	for _, link := range []struct{ name, pattern string }{
+		{"EMAIL_LINK_VERIFICATION", c.EmailLinks.Verification},
+		{"EMAIL_LINK_PASSWORD_RESET", c.EmailLinks.PasswordReset},
+		{"EMAIL_LINK_EMAIL_CHANGE", c.EmailLinks.EmailChange},
+		{"EMAIL_LINK_INVITATION", c.EmailLinks.Invitation},
+	} {
+		if !strings.Contains(link.pattern, "{token}") {
+			problems = append(problems, fmt.Sprintf("%s must contain {token}: %q", link.name, link.pattern))
+		}
+	}
+	if _, err := utils.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES: %v", err))
+	}
+	for _, entry := range c.RouteTimeouts {
+		if _, _, err := ParseRouteTimeout(entry); err != nil {
+			problems = append(problems, fmt.Sprintf("ROUTE_TIMEOUTS: %v", err))
+		}
+	}
+	return problems
+}
+
+// Proxies returns the parsed TRUSTED_PROXIES.
+func (c *Config) Proxies() utils.TrustedProxies {
+	proxies, _ := utils.ParseTrustedProxies(c.TrustedProxies)
+	return proxies
+}
+
+// ParseRouteTimeout parses an entry of ROUTE_TIMEOUTS, of the form
+// "[METHOD ]TEMPLATE=DURATION", e.g. "POST /inventory/api/products=1m". The template
+// is the route's full path template; without a method, the timeout applies to every
+// method of the route. The route is returned as it is looked up, e.g.
+// "POST /inventory/api/products" or "/inventory/api/products".
+func ParseRouteTimeout(entry string) (route string, timeout time.Duration, err error) {
+	i := strings.LastIndex(entry, "=")
+	if i < 0 {
+		return "", 0, fmt.Errorf("%q is not of the form [METHOD ]TEMPLATE=DURATION", entry)
+	}
+	timeout, err = time.ParseDuration(strings.TrimSpace(entry[i+1:]))
+	if err != nil || timeout <= 0 {
+		return "", 0, fmt.Errorf("%q: invalid duration %q", entry, strings.TrimSpace(entry[i+1:]))
+	}
+	parts := strings.Fields(entry[:i])
+	if len(parts) == 2 {
+		parts[0] = strings.ToUpper(parts[0])
+	}
+	if len(parts) == 0 || len(parts) > 2 || !strings.HasPrefix(parts[len(parts)-1], "/") {
+		return "", 0, fmt.Errorf("%q: the route must be a path template, optionally preceded by a method", entry)
+	}
+	return strings.Join(parts, " "), timeout, nil
+}
+
+// TimeoutsByRoute returns the timeouts of ROUTE_TIMEOUTS by route, as returned by
+// ParseRouteTimeout.
+func (c *Config) TimeoutsByRoute() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(c.RouteTimeouts))
+	for _, entry := range c.RouteTimeouts {
+		if route, timeout, err := ParseRouteTimeout(entry); err == nil {
+			timeouts[route] = timeout
+		}
+	}
+	return timeouts
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of a secret when the configuration is printed.
+const redacted = "[redacted]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is one setting of Config, described by its struct tags.
+type field struct {
+	name  string // Environment variable name, e.g. MONGO_URL
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+// fieldsOf returns the settings of cfg in declaration order, descending into nested
+// structs such as EmailLinks.
+func fieldsOf(cfg interface{}) []field {
+	var fields []field
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if name, ok := sf.Tag.Lookup("config"); ok {
+			fields = append(fields, field{name: name, value: v.Field(i), tag: sf.Tag})
+		} else if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, fieldsOf(v.Field(i).Addr().Interface())...)
+		}
+	}
+	return fields
+}
+
+func (f field) key() string      { return strings.ToLower(f.name) }
+func (f field) flagName() string { return strings.ReplaceAll(f.key(), "_", "-") }
+func (f field) secret() bool     { return f.tag.Get("secret") == "true" }
+
+// set parses raw into the field. Lists are comma-separated and durations use Go
+// syntax, e.g. "1m30s".
+func (f field) set(raw string) error {
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		f.value.SetString(raw)
+	}
+	return nil
+}
+
+// apply sets the field from raw, reporting a problem against source.
+func (f field) apply(source, raw string) []string {
+	if err := f.set(raw); err != nil {
+		return []string{fmt.Sprintf("%s: %v", source, err)}
+	}
+	return nil
+}
+
+// applySecretFile sets the field to the contents of the file at path, without the
+// trailing newline editors and secret mounts tend to add.
+func (f field) applySecretFile(source, path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", source, err)}
+	}
+	return f.apply(source, strings.TrimRight(string(data), "\r\n"))
+}
+
+func (f field) validate() []string {
+	var problems []string
+	if f.tag.Get("required") == "true" && f.value.IsZero() {
+		problems = append(problems, fmt.Sprintf("%s is required", f.name))
+	}
+	if oneof, ok := f.tag.Lookup("oneof"); ok {
+		allowed := strings.Fields(oneof)
+		if value := f.value.String(); !contains(allowed, value) {
+			problems = append(problems, fmt.Sprintf("%s must be one of %s: %q", f.name, strings.Join(allowed, ", "), value))
+		}
+	}
+	if minStr, ok := f.tag.Lookup("min"); ok {
+		if min, _ := strconv.Atoi(minStr); f.value.Int() < int64(min) {
+			problems = append(problems, fmt.Sprintf("%s must be at least %d: %d", f.name, min, f.value.Int()))
+		}
+	}
+	return problems
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFile sets the fields found in the YAML file at path. Keys are the lower-case
+// setting names, plus <name>_file for secrets; lists may be YAML sequences or
+// comma-separated strings. A missing file is only a problem when required.
+func applyFile(fields []field, path string, required bool) []string {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []string{fmt.Sprintf("%s: %v", path, err)}
+	}
+
+	byKey := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byKey[f.key()] = f
+	}
+
+	var problems []string
+	for _, f := range fields {
+		source := fmt.Sprintf("%s: %s", path, f.key())
+		if node, ok := doc[f.key()]; ok {
+			raw, err := scalarOrList(&node)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", source, err))
+			} else {
+				problems = append(problems, f.apply(source, raw)...)
+			}
+		}
+		if node, ok := doc[f.key()+"_file"]; ok && f.secret() {
+			problems = append(problems, f.applySecretFile(source+"_file", node.Value)...)
+		}
+	}
+	// Report unknown keys, which are most likely typos
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if f, ok := byKey[strings.TrimSuffix(key, "_file")]; ok && (key == f.key() || f.secret()) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s: unknown setting %q", path, key))
+	}
+	return problems
+}
+
+func scalarOrList(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value, nil
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return "", errors.New("list items must be plain values")
+			}
+			items = append(items, item.Value)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", errors.New("must be a plain value or a list")
+	}
+}
+
+// Print writes the configuration as YAML, in the format of the configuration file,
+// with the values of secrets redacted.
+func Print(w io.Writer, cfg *Config) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range fieldsOf(cfg) {
+		var value interface{} = f.value.Interface()
+		switch {
+		case f.secret() && !f.value.IsZero():
+			value = redacted
+		case f.value.Type() == durationType:
+			value = time.Duration(f.value.Int()).String()
+		}
+		node := &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key()}, node)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"inventory-service/infrastructure/metrics"
+	"inventory-service/infrastructure/tracing"
 	"time"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,7 +19,8 @@ func NewMongoClient(url string) (*MongoClient, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url))
+	monitor := combineMonitors(metrics.MongoMonitor(), tracing.MongoMonitor())
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url).SetMonitor(monitor))
 	if err != nil {
 		return nil, err
 	}
@@ -34,3 +38,31 @@ func (c *MongoClient) Disconnect() {
 	defer cancel()
 	c.Client.Disconnect(ctx)
 }
+
+// combineMonitors returns a monitor calling each of monitors in turn, as the driver
+// takes a single one.
+func combineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
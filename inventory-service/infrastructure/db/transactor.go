@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoTransactor runs functions in MongoDB multi-document transactions.
+type MongoTransactor struct {
+	client    *MongoClient
+	supported bool
+}
+
+// NewTransactor checks whether the server supports transactions, which requires a
+// replica set or a sharded cluster. On a standalone server, as used in local
+// development, functions run without a transaction.
+func NewTransactor(client *MongoClient) *MongoTransactor {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var hello bson.M
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	supported := err == nil && (hello["setName"] != nil || hello["msg"] == "isdbgrid")
+	if !supported {
+		slog.Warn("MongoDB does not support transactions; writes will not be atomic")
+	}
+	return &MongoTransactor{client: client, supported: supported}
+}
+
+func (t *MongoTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !t.supported {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	// WithTransaction retries fn on transient errors, so fn must not have side effects
+	// outside the database
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
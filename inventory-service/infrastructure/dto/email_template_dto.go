@@ -0,0 +1,14 @@
+package dto
+
+type SaveEmailTemplateDTO struct {
+	Locale string `json:"locale" validate:"omitempty,bcp47_language_tag"` // Empty overrides the default variant
+	Source string `json:"source" validate:"required"`
+}
+
+type EmailTemplatePreviewDTO struct {
+	Name    string `json:"name"`
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
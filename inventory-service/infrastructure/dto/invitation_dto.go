@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type CreateInvitationDTO struct {
+	Email  string `json:"email" validate:"required,email"`
+	Role   string `json:"role" validate:"required,oneof=admin user"`
+	Locale string `json:"locale" validate:"omitempty,bcp47_language_tag"`
+}
+
+type AcceptInvitationDTO struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+	Name     string `json:"name" validate:"max=100"`
+}
+
+type InvitationDTO struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Locale     string     `json:"locale,omitempty"`
+	Status     string     `json:"status"`
+	InvitedBy  string     `json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
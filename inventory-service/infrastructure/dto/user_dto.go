@@ -1,8 +1,14 @@
 package dto
 
+import (
+	"inventory-service/domain/models"
+	"time"
+)
+
 type RegisterUserDTO struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
+	Locale   string `json:"locale" validate:"omitempty,bcp47_language_tag"`
 }
 
 type LoginUserDTO struct {
@@ -14,16 +20,25 @@ type RequestPasswordResetDTO struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+type ResendVerificationDTO struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
 type ResetPasswordDTO struct {
-	Token       string `json:"token" validate:"required"`
+	Token       string `json:"token" validate:"required"` // Taken from the URL when present
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
 type UserDTO struct {
-	ID         string `json:"id,omitempty"`
-	Email      string `json:"email"`
-	Role       string `json:"role"`
-	IsVerified bool   `json:"is_verified"`
+	ID          string     `json:"id,omitempty"`
+	Email       string     `json:"email"`
+	Name        string     `json:"name"`
+	Role        string     `json:"role"`
+	Locale      string     `json:"locale,omitempty"`
+	IsVerified  bool       `json:"is_verified"`
+	MFAEnabled  bool       `json:"mfa_enabled"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
 }
 
 type UpdateUserRequest struct {
@@ -31,3 +46,73 @@ type UpdateUserRequest struct {
 	Role       string `json:"role" validate:"oneof=admin user"`
 	IsVerified bool   `json:"is_verified"`
 }
+
+type LoginResponse struct {
+	Token                 string `json:"token,omitempty"`
+	MFARequired           bool   `json:"mfa_required,omitempty"`
+	MFAToken              string `json:"mfa_token,omitempty"`               // Exchange at /users/login/mfa together with a code
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"` // The user's role requires enrolling a second factor
+}
+
+type VerifyMFADTO struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP code or recovery code
+}
+
+type MFACodeDTO struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFAConfirmationResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token"`
+}
+
+type UpdateProfileRequest struct {
+	Name   string `json:"name" validate:"max=100"`
+	Locale string `json:"locale" validate:"omitempty,bcp47_language_tag"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+type ChangeEmailDTO struct {
+	NewEmail        string `json:"new_email" validate:"required,email"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+type DeleteAccountDTO struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountExport is everything stored about a user, as returned by the data export.
+// Secrets such as the password hash, token hashes and the TOTP secret are left out.
+type AccountExport struct {
+	ExportedAt             time.Time               `json:"exported_at"`
+	Profile                UserDTO                 `json:"profile"`
+	PendingEmail           string                  `json:"pending_email,omitempty"`
+	VerificationPending    bool                    `json:"verification_pending"`
+	PasswordResetPending   bool                    `json:"password_reset_pending"`
+	RecoveryCodesRemaining int                     `json:"recovery_codes_remaining"`
+	Activity               []*models.AuditEntry    `json:"activity"`         // Audited actions the user performed or that target them
+	Invitations            []*models.Invitation    `json:"invitations"`      // Sent to or by the user
+	EmailDeliveries        []*models.EmailDelivery `json:"email_deliveries"` // Emails sent or being sent to the user
+	QueuedEmails           []QueuedEmail           `json:"queued_emails"`    // Emails not handed to the email worker yet
+}
+
+// QueuedEmail describes an email waiting in the outbox. Its body is left out, as it
+// may hold a link with a one-time token.
+type QueuedEmail struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	To       string    `json:"to"`
+	Subject  string    `json:"subject"`
+	QueuedAt time.Time `json:"queued_at"`
+}
@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of the service and of its components, as Spring Boot reports them.
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// Check pings one dependency of the service.
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+// ComponentReport is the outcome of a Check.
+type ComponentReport struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the outcome of every Check; the service is up only when all of its
+// components are.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// Checker runs the checks deciding whether the service is ready for traffic.
+type Checker struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewChecker returns a Checker giving each check up to timeout to answer.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Drain has the service reported down from now on, whatever its components say, so
+// that it gets no new traffic while shutting down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Check runs the checks concurrently and reports on each of them.
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentReport, len(c.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			component := c.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.Name] = component
+			if component.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+	wg.Wait()
+	if c.draining.Load() {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	// Not every client honours the context, so the timeout is enforced here too
+	go func() { errc <- check.Ping(ctx) }()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = fmt.Errorf("no answer within %s", c.timeout)
+	}
+
+	component := ComponentReport{Status: StatusUp, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
+
+// Watch runs the checks every interval until ctx is done, calling onChange with the
+// first outcome and with every change after it. A change onChange fails on is
+// offered again after the next run.
+func (c *Checker) Watch(ctx context.Context, interval time.Duration, onChange func(ctx context.Context, report Report) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	status := ""
+	for {
+		report := c.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if report.Status != status {
+			if status != "" {
+				slog.WarnContext(ctx, "Readiness changed", "from", status, "to", report.Status, "components", report.Components)
+			}
+			if err := onChange(ctx, report); err != nil {
+				slog.ErrorContext(ctx, "Failed to act on readiness", "status", report.Status, "error", err)
+			} else {
+				status = report.Status
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventory-service/application"
+	"inventory-service/infrastructure/dto"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type AccountHandler struct {
+	usecase   *application.AccountUsecase
+	validator *validator.Validate
+}
+
+func NewAccountHandler(usecase *application.AccountUsecase) *AccountHandler {
+	return &AccountHandler{
+		usecase:   usecase,
+		validator: newValidator(),
+	}
+}
+
+func (h *AccountHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(string)
+
+	user, err := h.usecase.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *AccountHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(string)
+	user, err := h.usecase.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *AccountHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var changeDTO dto.ChangePasswordDTO
+	if err := json.NewDecoder(r.Body).Decode(&changeDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, changeDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(string)
+	mfa, _ := r.Context().Value("mfa").(bool)
+	token, err := h.usecase.ChangePassword(r.Context(), userID, changeDTO.CurrentPassword, changeDTO.NewPassword, mfa)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.LoginResponse{Token: token})
+}
+
+func (h *AccountHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	var changeDTO dto.ChangeEmailDTO
+	if err := json.NewDecoder(r.Body).Decode(&changeDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, changeDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(string)
+	if err := h.usecase.RequestEmailChange(r.Context(), userID, changeDTO.NewEmail, changeDTO.CurrentPassword); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Confirmation email sent to the new address"))
+}
+
+func (h *AccountHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	token := vars["token"]
+
+	err := h.usecase.ConfirmEmailChange(r.Context(), token)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Email address changed successfully"))
+}
+
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	var deleteDTO dto.DeleteAccountDTO
+	if err := json.NewDecoder(r.Body).Decode(&deleteDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, deleteDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(string)
+	if err := h.usecase.DeleteAccount(r.Context(), userID, deleteDTO.Password); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AccountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(string)
+
+	export, err := h.usecase.Export(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="account-export.json"`)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(export)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventory-service/application"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"net/http"
+	"strconv"
+)
+
+type AuditHandler struct {
+	usecase *application.AuditUsecase
+}
+
+func NewAuditHandler(usecase *application.AuditUsecase) *AuditHandler {
+	return &AuditHandler{usecase: usecase}
+}
+
+func (h *AuditHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+	var err error
+	if filter.From, err = parseDateParam(query.Get("from"), false); err != nil {
+		writeBadRequest(w, r, "Invalid from date")
+		return
+	}
+	if filter.To, err = parseDateParam(query.Get("to"), true); err != nil {
+		writeBadRequest(w, r, "Invalid to date")
+		return
+	}
+
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		if val, err := strconv.Atoi(pageStr); err == nil && val > 0 {
+			page = val
+		}
+	}
+
+	limit := 20 // Default limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 {
+			limit = min(val, 100)
+		}
+	}
+
+	entries, total, err := h.usecase.Find(r.Context(), filter, page, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := struct {
+		Entries    []*models.AuditEntry `json:"entries"`
+		Total      int64                `json:"total"`
+		Page       int                  `json:"page"`
+		Limit      int                  `json:"limit"`
+		TotalPages int                  `json:"total_pages"`
+	}{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)), // Ceiling division
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
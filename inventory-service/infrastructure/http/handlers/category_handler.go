@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"inventory-service/application"
+	"inventory-service/infrastructure/audit"
 	"inventory-service/infrastructure/dto"
 	"net/http"
 
@@ -18,28 +19,30 @@ type CategoryHandler struct {
 func NewCategoryHandler(usecase *application.CategoryUsecase) *CategoryHandler {
 	return &CategoryHandler{
 		usecase:   usecase,
-		validator: validator.New(),
+		validator: newValidator(),
 	}
 }
 
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var createDTO dto.CreateCategoryDTO
 	if err := json.NewDecoder(r.Body).Decode(&createDTO); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	if err := h.validator.Struct(createDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, createDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	category := createDTO.ToModel()
-	err := h.usecase.Create(category)
+	err := h.usecase.Create(r.Context(), category)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	audit.SetTargetID(r.Context(), category.ID.Hex())
+	audit.SetAfter(r.Context(), category)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(category)
@@ -48,22 +51,22 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	var updateDTO dto.UpdateCategoryDTO
 	if err := json.NewDecoder(r.Body).Decode(&updateDTO); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
 	vars := mux.Vars(r)
 	updateDTO.ID = vars["id"]
 
-	if err := h.validator.Struct(updateDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, updateDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	category := updateDTO.ToModel()
-	err := h.usecase.Update(category)
+	err := h.usecase.Update(r.Context(), category)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -75,9 +78,9 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	err := h.usecase.Delete(id)
+	err := h.usecase.Delete(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -88,13 +91,9 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	category, err := h.usecase.GetByID(id)
+	category, err := h.usecase.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-	if category == nil {
-		http.Error(w, "Category not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
@@ -103,9 +102,9 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.usecase.GetAll()
+	categories, err := h.usecase.GetAll(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventory-service/application"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"net/http"
+	"strconv"
+)
+
+type EmailDeliveryHandler struct {
+	usecase *application.EmailDeliveryUsecase
+}
+
+func NewEmailDeliveryHandler(usecase *application.EmailDeliveryUsecase) *EmailDeliveryHandler {
+	return &EmailDeliveryHandler{usecase: usecase}
+}
+
+func (h *EmailDeliveryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	query := r.URL.Query()
+	filter := domain.EmailDeliveryFilter{
+		Status: query.Get("status"),
+		To:     query.Get("to"),
+		Type:   query.Get("type"),
+	}
+	switch filter.Status {
+	case "", models.EmailDeliveryRetrying, models.EmailDeliverySent, models.EmailDeliveryDeadLettered:
+	default:
+		writeBadRequest(w, r, "Invalid status filter")
+		return
+	}
+
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		if val, err := strconv.Atoi(pageStr); err == nil && val > 0 {
+			page = val
+		}
+	}
+
+	limit := 20 // Default limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 {
+			limit = min(val, 100)
+		}
+	}
+
+	deliveries, total, err := h.usecase.Find(r.Context(), filter, page, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := struct {
+		Deliveries []*models.EmailDelivery `json:"deliveries"`
+		Total      int64                   `json:"total"`
+		Page       int                     `json:"page"`
+		Limit      int                     `json:"limit"`
+		TotalPages int                     `json:"total_pages"`
+	}{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)), // Ceiling division
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventory-service/application"
+	"inventory-service/infrastructure/audit"
+	"inventory-service/infrastructure/dto"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type EmailTemplateHandler struct {
+	usecase   *application.EmailTemplateUsecase
+	validator *validator.Validate
+}
+
+func NewEmailTemplateHandler(usecase *application.EmailTemplateUsecase) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		usecase:   usecase,
+		validator: newValidator(),
+	}
+}
+
+func (h *EmailTemplateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	response := struct {
+		Templates []string `json:"templates"`
+	}{
+		Templates: h.usecase.List(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Preview renders a template with sample data. By default the subject and both parts
+// are returned as JSON; format=html or format=text returns that part on its own, so
+// the HTML can be viewed directly in a browser.
+func (h *EmailTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	preview, err := h.usecase.Preview(r.Context(), name, r.URL.Query().Get("locale"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(preview.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(preview.Text))
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(preview)
+	default:
+		writeBadRequest(w, r, "Invalid format")
+	}
+}
+
+func (h *EmailTemplateHandler) Save(w http.ResponseWriter, r *http.Request) {
+	var saveDTO dto.SaveEmailTemplateDTO
+	if err := json.NewDecoder(r.Body).Decode(&saveDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, saveDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	adminID := r.Context().Value("user_id").(string)
+	preview, err := h.usecase.Save(r.Context(), mux.Vars(r)["name"], adminID, &saveDTO)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	audit.SetAfter(r.Context(), saveDTO)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preview)
+}
+
+func (h *EmailTemplateHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.Reset(r.Context(), mux.Vars(r)["name"], r.URL.Query().Get("locale")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
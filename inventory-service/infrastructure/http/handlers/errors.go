@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inventory-service/application"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/http/problem"
+	"log/slog"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// statusClientClosedRequest is the non-standard status, borrowed from nginx, of a
+// request whose client went away before it was answered.
+const statusClientClosedRequest = 499
+
+// errorStatuses maps each kind of domain error to its HTTP status.
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{domain.ErrValidation, http.StatusBadRequest},
+	{domain.ErrUnauthorized, http.StatusUnauthorized},
+	{domain.ErrForbidden, http.StatusForbidden},
+	{domain.ErrNotFound, http.StatusNotFound},
+	{domain.ErrConflict, http.StatusConflict},
+	{domain.ErrRateLimited, http.StatusTooManyRequests},
+}
+
+// writeError answers r with the problem matching err. Domain errors are reported
+// with their message; any other error is logged and reported without details, so
+// that database errors and the like never reach clients, unless the request timed
+// out or was cancelled.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooMany *application.TooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tooMany.RetryAfter.Seconds()))))
+	}
+
+	for _, e := range errorStatuses {
+		if !errors.Is(err, e.kind) {
+			continue
+		}
+		p := problem.New(r, e.status, err.Error())
+		// Invalid fields are listed on their own rather than in the detail
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) && len(invalid.Fields) > 0 {
+			p.Detail, p.Errors = invalid.Message, invalid.Fields
+		}
+		problem.WriteProblem(w, p)
+		return
+	}
+
+	// The calls made for the request are aborted with its context, so once that ends
+	// their errors are only a symptom
+	switch r.Context().Err() {
+	case context.DeadlineExceeded:
+		slog.WarnContext(r.Context(), "Request timed out", "method", r.Method, "path", r.URL.Path, "error", err)
+		problem.Write(w, r, http.StatusServiceUnavailable, "The request timed out, please try again later")
+		return
+	case context.Canceled:
+		// Nobody is left to read a response; the status is for metrics and the audit log
+		slog.DebugContext(r.Context(), "Request cancelled by the client", "method", r.Method, "path", r.URL.Path, "error", err)
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	problem.Write(w, r, http.StatusInternalServerError, "The request could not be completed, please try again later")
+}
+
+// writeBadRequest answers a request that is invalid as a whole, e.g. whose body
+// could not be decoded.
+func writeBadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	writeError(w, r, domain.NewValidationError(detail))
+}
+
+// newValidator returns a validator naming fields by their JSON names, as clients
+// know them.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// validate checks req against its validate tags, returning the problem with each
+// invalid field.
+func validate(v *validator.Validate, req interface{}) error {
+	err := v.Struct(req)
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+	fields := make([]domain.FieldError, len(invalid))
+	for i, fe := range invalid {
+		// The namespace starts with the name of the request type
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		fields[i] = domain.FieldError{Field: path, Message: fieldMessage(fe)}
+	}
+	return domain.NewValidationError("The request has invalid fields", fields...)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "bcp47_language_tag":
+		return "must be a language tag, e.g. en or pt-BR"
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+		}
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+			return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	default:
+		return "is invalid (" + fe.Tag() + ")"
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventory-service/application"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/audit"
+	"inventory-service/infrastructure/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+type InvitationHandler struct {
+	usecase   *application.InvitationUsecase
+	validator *validator.Validate
+}
+
+func NewInvitationHandler(usecase *application.InvitationUsecase) *InvitationHandler {
+	return &InvitationHandler{
+		usecase:   usecase,
+		validator: newValidator(),
+	}
+}
+
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var createDTO dto.CreateInvitationDTO
+	if err := json.NewDecoder(r.Body).Decode(&createDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, createDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	adminID := r.Context().Value("user_id").(string)
+	invitation, err := h.usecase.Create(r.Context(), adminID, &createDTO)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	audit.SetTargetID(r.Context(), invitation.ID)
+	audit.SetAfter(r.Context(), invitation)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
+}
+
+func (h *InvitationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.InvitationPending, models.InvitationAccepted, models.InvitationRevoked, models.InvitationExpired:
+	default:
+		writeBadRequest(w, r, "Invalid status filter")
+		return
+	}
+
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if val, err := strconv.Atoi(pageStr); err == nil && val > 0 {
+			page = val
+		}
+	}
+
+	limit := 10 // Default limit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 {
+			limit = min(val, 100)
+		}
+	}
+
+	invitations, total, err := h.usecase.GetAll(r.Context(), status, page, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := struct {
+		Invitations []*dto.InvitationDTO `json:"invitations"`
+		Total       int64                `json:"total"`
+		Page        int                  `json:"page"`
+		Limit       int                  `json:"limit"`
+		TotalPages  int                  `json:"total_pages"`
+	}{
+		Invitations: invitations,
+		Total:       total,
+		Page:        page,
+		Limit:       limit,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)), // Ceiling division
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.usecase.Revoke(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var acceptDTO dto.AcceptInvitationDTO
+	if err := json.NewDecoder(r.Body).Decode(&acceptDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, acceptDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.usecase.Accept(r.Context(), &acceptDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("Invitation accepted, you can now log in"))
+}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"inventory-service/application"
 	"inventory-service/domain"
 	"inventory-service/domain/models"
+	"inventory-service/infrastructure/audit"
 	"inventory-service/infrastructure/dto"
 	"inventory-service/infrastructure/services"
 	"net/http"
@@ -24,50 +26,52 @@ func NewProductHandler(usecase *application.ProductUsecase, cloudinarySvc *servi
 	return &ProductHandler{
 		usecase:       usecase,
 		cloudinarySvc: cloudinarySvc,
-		validator:     validator.New(),
+		validator:     newValidator(),
 	}
 }
 
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseMultipartForm(10 << 20) // 10 MB limit
 	if err != nil {
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		writeBadRequest(w, r, "Unable to parse form")
 		return
 	}
 
 	var createDTO dto.CreateProductDTO
 	err = json.Unmarshal([]byte(r.FormValue("product")), &createDTO)
 	if err != nil {
-		http.Error(w, "Invalid product data", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid product data")
 		return
 	}
 
-	if err := h.validator.Struct(createDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, createDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	file, _, err := r.FormFile("image")
 	if err != nil {
-		http.Error(w, "Image required", http.StatusBadRequest)
+		writeBadRequest(w, r, "Image required")
 		return
 	}
 	defer file.Close()
 
-	imageURL, err := h.cloudinarySvc.UploadImage(file)
+	imageURL, err := h.cloudinarySvc.UploadImage(r.Context(), file)
 	if err != nil {
-		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+		writeError(w, r, fmt.Errorf("failed to upload image: %w", err))
 		return
 	}
 
 	product := createDTO.ToModel()
 	product.ImageURL = imageURL
 
-	err = h.usecase.Create(product)
+	err = h.usecase.Create(r.Context(), product)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	audit.SetTargetID(r.Context(), product.ID.Hex())
+	audit.SetAfter(r.Context(), product)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(product)
@@ -76,28 +80,28 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseMultipartForm(10 << 20)
 	if err != nil {
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		writeBadRequest(w, r, "Unable to parse form")
 		return
 	}
 
 	productJSON := r.FormValue("product")
 	if productJSON == "" {
-		http.Error(w, "Missing product data", http.StatusBadRequest)
+		writeBadRequest(w, r, "Missing product data")
 		return
 	}
 
 	var updateDTO dto.UpdateProductDTO
 	err = json.Unmarshal([]byte(productJSON), &updateDTO)
 	if err != nil {
-		http.Error(w, "Invalid product data", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid product data")
 		return
 	}
 
 	vars := mux.Vars(r)
 	updateDTO.ID = vars["id"]
 
-	if err := h.validator.Struct(updateDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, updateDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -105,28 +109,24 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 	file, _, err := r.FormFile("image")
 	if err == nil {
-		imageURL, err := h.cloudinarySvc.UploadImage(file)
+		imageURL, err := h.cloudinarySvc.UploadImage(r.Context(), file)
 		if err != nil {
-			http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+			writeError(w, r, fmt.Errorf("failed to upload image: %w", err))
 			return
 		}
 		product.ImageURL = imageURL
 	} else {
-		fetchedProduct, err := h.usecase.GetByID(updateDTO.ID)
+		fetchedProduct, err := h.usecase.GetByID(r.Context(), updateDTO.ID)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if fetchedProduct == nil {
-			http.Error(w, "Product not found", http.StatusNotFound)
+			writeError(w, r, err)
 			return
 		}
 		product.ImageURL = fetchedProduct.ImageURL
 	}
 
-	err = h.usecase.Update(product)
+	err = h.usecase.Update(r.Context(), product)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -138,9 +138,9 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	err := h.usecase.Delete(id)
+	err := h.usecase.Delete(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -151,13 +151,9 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	product, err := h.usecase.GetByID(id)
+	product, err := h.usecase.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-	if product == nil {
-		http.Error(w, "Product not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
@@ -207,7 +203,7 @@ func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request)
 
 	products, total, err := h.usecase.GetAll(r.Context(), filter, sort, page, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
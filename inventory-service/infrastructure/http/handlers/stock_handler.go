@@ -3,9 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"inventory-service/application"
+	"inventory-service/infrastructure/audit"
 	"inventory-service/infrastructure/dto"
 	"net/http"
-	"fmt"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -17,22 +17,19 @@ type StockHandler struct {
 func NewStockHandler(usecase *application.StockUsecase) *StockHandler {
 	return &StockHandler{
 		usecase:   usecase,
-		validator: validator.New(),
+		validator: newValidator(),
 	}
 }
 
 func (h *StockHandler) BulkUpdateStock(w http.ResponseWriter, r *http.Request) {
 	var req dto.BulkStockUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	fmt.Println(req)
-
-
-	if err := h.validator.Struct(req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -51,9 +48,10 @@ func (h *StockHandler) BulkUpdateStock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.usecase.BulkUpdateStock(r.Context(), updates); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
+	audit.SetAfter(r.Context(), req)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"inventory-service/application"
 	"inventory-service/infrastructure/dto"
+	"inventory-service/utils"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
@@ -18,25 +19,25 @@ type UserHandler struct {
 func NewUserHandler(usecase *application.UserUsecase) *UserHandler {
 	return &UserHandler{
 		usecase:   usecase,
-		validator: validator.New(),
+		validator: newValidator(),
 	}
 }
 
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var registerDTO dto.RegisterUserDTO
 	if err := json.NewDecoder(r.Body).Decode(&registerDTO); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	if err := h.validator.Struct(registerDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, registerDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	err := h.usecase.Register(registerDTO.Email, registerDTO.Password)
+	err := h.usecase.Register(r.Context(), registerDTO.Email, registerDTO.Password, registerDTO.Locale)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -47,32 +48,118 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var loginDTO dto.LoginUserDTO
 	if err := json.NewDecoder(r.Body).Decode(&loginDTO); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	if err := h.validator.Struct(loginDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, loginDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	token, err := h.usecase.Login(loginDTO.Email, loginDTO.Password)
+	response, err := h.usecase.Login(r.Context(), loginDTO.Email, loginDTO.Password, utils.ClientIP(r))
 	if err != nil {
-		http.Error(w, "Invalid credentials or unverified email", http.StatusUnauthorized)
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *UserHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var verifyDTO dto.VerifyMFADTO
+	if err := json.NewDecoder(r.Body).Decode(&verifyDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, verifyDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	token, err := h.usecase.VerifyMFA(r.Context(), verifyDTO.MFAToken, verifyDTO.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.LoginResponse{Token: token})
+}
+
+func (h *UserHandler) BeginMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(string)
+
+	enrollment, err := h.usecase.BeginMFAEnrollment(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+func (h *UserHandler) ConfirmMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	var codeDTO dto.MFACodeDTO
+	if err := json.NewDecoder(r.Body).Decode(&codeDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, codeDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(string)
+	confirmation, err := h.usecase.ConfirmMFAEnrollment(r.Context(), userID, codeDTO.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(confirmation)
+}
+
+func (h *UserHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	var codeDTO dto.MFACodeDTO
+	if err := json.NewDecoder(r.Body).Decode(&codeDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, codeDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(string)
+	token, err := h.usecase.DisableMFA(r.Context(), userID, codeDTO.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.LoginResponse{Token: token})
 }
 
 func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	token := vars["token"]
 
-	err := h.usecase.VerifyEmail(token)
+	err := h.usecase.VerifyEmail(r.Context(), token)
 	if err != nil {
-		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -80,21 +167,43 @@ func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Email verified successfully"))
 }
 
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var resendDTO dto.ResendVerificationDTO
+	if err := json.NewDecoder(r.Body).Decode(&resendDTO); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := validate(h.validator, resendDTO); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err := h.usecase.ResendVerification(r.Context(), resendDTO.Email)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("If the account exists and is not yet verified, a new verification email has been sent"))
+}
+
 func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
 	var requestDTO dto.RequestPasswordResetDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestDTO); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	if err := h.validator.Struct(requestDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, requestDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	err := h.usecase.RequestPasswordReset(requestDTO.Email)
+	err := h.usecase.RequestPasswordReset(r.Context(), requestDTO.Email)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -105,18 +214,22 @@ func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Reques
 func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	var resetDTO dto.ResetPasswordDTO
 	if err := json.NewDecoder(r.Body).Decode(&resetDTO); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	if err := h.validator.Struct(resetDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if token := mux.Vars(r)["token"]; token != "" {
+		resetDTO.Token = token
+	}
+
+	if err := validate(h.validator, resetDTO); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	err := h.usecase.ResetPassword(resetDTO.Token, resetDTO.NewPassword)
+	err := h.usecase.ResetPassword(r.Context(), resetDTO.Token, resetDTO.NewPassword)
 	if err != nil {
-		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
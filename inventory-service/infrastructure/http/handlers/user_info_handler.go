@@ -3,8 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"inventory-service/application"
+	"inventory-service/domain"
 	"inventory-service/infrastructure/dto"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -18,7 +21,7 @@ type UserInfoHandler struct {
 func NewUserInfoHandler(usecase *application.UserInfoUsecase) *UserInfoHandler {
 	return &UserInfoHandler{
 		usecase:   usecase,
-		validator: validator.New(),
+		validator: newValidator(),
 	}
 }
 
@@ -28,7 +31,7 @@ func (h *UserInfoHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.usecase.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
@@ -38,15 +41,95 @@ func (h *UserInfoHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserInfoHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	users, err := h.usecase.GetAll(r.Context())
+	// Parse query parameters
+	query := r.URL.Query()
+	filter := domain.UserFilter{
+		Email: query.Get("email"),
+		Role:  query.Get("role"),
+	}
+	if verified := query.Get("verified"); verified != "" {
+		val, err := strconv.ParseBool(verified)
+		if err != nil {
+			writeBadRequest(w, r, "Invalid verified filter, expected true or false")
+			return
+		}
+		filter.IsVerified = &val
+	}
+	var err error
+	if filter.CreatedFrom, err = parseDateParam(query.Get("created_from"), false); err != nil {
+		writeBadRequest(w, r, "Invalid created_from date")
+		return
+	}
+	if filter.CreatedTo, err = parseDateParam(query.Get("created_to"), true); err != nil {
+		writeBadRequest(w, r, "Invalid created_to date")
+		return
+	}
+
+	sort := domain.UserSort{
+		Field: query.Get("sort"), // e.g., "email", "created_at", "last_login_at"
+		Order: 1,                 // Default ascending
+	}
+	if order := query.Get("order"); order == "desc" {
+		sort.Order = -1
+	}
+
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		if val, err := strconv.Atoi(pageStr); err == nil && val > 0 {
+			page = val
+		}
+	}
+
+	limit := 10 // Default limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 {
+			limit = min(val, 100)
+		}
+	}
+
+	users, total, err := h.usecase.GetAll(r.Context(), filter, sort, page, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	// Response structure with pagination metadata
+	response := struct {
+		Users      []*dto.UserDTO `json:"users"`
+		Total      int64          `json:"total"`
+		Page       int            `json:"page"`
+		Limit      int            `json:"limit"`
+		TotalPages int            `json:"total_pages"`
+	}{
+		Users:      users,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)), // Ceiling division
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(users)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseDateParam accepts an RFC 3339 timestamp or a plain date; a plain date used
+// as an upper bound covers the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
 }
 
 func (h *UserInfoHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -55,18 +138,18 @@ func (h *UserInfoHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	var req dto.UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid request body")
 		return
 	}
 
-	if err := h.validator.Struct(req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := validate(h.validator, req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	user, err := h.usecase.Update(r.Context(), id, &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -80,7 +163,19 @@ func (h *UserInfoHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	if err := h.usecase.Delete(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserInfoHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.usecase.Unlock(r.Context(), id); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/audit"
+	"inventory-service/infrastructure/logging"
+	"inventory-service/utils"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type AuditRecorder interface {
+	Record(ctx context.Context, entry *models.AuditEntry) error
+}
+
+// AuditTarget describes what an audited route acts on.
+type AuditTarget struct {
+	Type string
+	// ID resolves the target ID from the request; defaults to the {id} route variable
+	ID func(r *http.Request) string
+	// Load fetches the target's current state for the before/after snapshots; optional
+	Load func(ctx context.Context, id string) (interface{}, error)
+}
+
+// Audit records every successful call of the wrapped handler in the audit log, with
+// snapshots of the target taken before and after the handler runs. Handlers can add
+// details through the audit package, e.g. the ID of a resource they created.
+func Audit(recorder AuditRecorder, action string, target AuditTarget) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, draft := audit.WithDraft(r.Context())
+			if target.ID != nil {
+				draft.TargetID = target.ID(r)
+			} else {
+				draft.TargetID = mux.Vars(r)["id"]
+			}
+
+			if target.Load != nil && draft.TargetID != "" {
+				if before, err := target.Load(ctx, draft.TargetID); err == nil {
+					draft.Before = audit.Snapshot(before)
+				}
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			if rec.status >= http.StatusBadRequest {
+				return
+			}
+
+			if target.Load != nil && draft.After == nil && draft.TargetID != "" && r.Method != http.MethodDelete {
+				if after, err := target.Load(ctx, draft.TargetID); err == nil {
+					draft.After = audit.Snapshot(after)
+				}
+			}
+
+			actorID, _ := r.Context().Value("user_id").(string)
+			actorRole, _ := r.Context().Value("role").(string)
+			entry := &models.AuditEntry{
+				OccurredAt: time.Now().UTC(),
+				ActorID:    actorID,
+				ActorRole:  actorRole,
+				Action:     action,
+				TargetType: target.Type,
+				TargetID:   draft.TargetID,
+				Before:     draft.Before,
+				After:      draft.After,
+				IP:         utils.ClientIP(r),
+				RequestID:  logging.RequestID(ctx),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     rec.status,
+			}
+			// The response has already been written, so a failure can only be logged
+			if err := recorder.Record(context.WithoutCancel(ctx), entry); err != nil {
+				slog.ErrorContext(ctx, "Failed to record audit entry", "action", action, "target_type", target.Type, "target_id", draft.TargetID, "error", err)
+			}
+		})
+	}
+}
+
+// SelfTarget resolves the target of /users/me routes to the authenticated user.
+func SelfTarget(r *http.Request) string {
+	userID, _ := r.Context().Value("user_id").(string)
+	return userID
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
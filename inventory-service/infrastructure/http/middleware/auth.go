@@ -2,44 +2,81 @@ package middleware
 
 import (
 	"context"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/http/problem"
 	"inventory-service/utils"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
-		}
+// AuthMiddleware validates the bearer token and rejects tokens issued before the
+// user's sessions were invalidated (e.g. by a password reset). The role is taken from
+// the stored user rather than the token, so that a role change applies at once.
+func AuthMiddleware(users domain.UserRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				problem.Write(w, r, http.StatusUnauthorized, "Authorization header required")
+				return
+			}
 
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			http.Error(w, "Invalid token format", http.StatusUnauthorized)
-			return
-		}
+			tokenParts := strings.Split(authHeader, " ")
+			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+				problem.Write(w, r, http.StatusUnauthorized, "Invalid token format")
+				return
+			}
 
-		claims, err := utils.ValidateJWT(tokenParts[1])
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			claims, err := utils.ValidateJWT(tokenParts[1])
+			if err != nil {
+				problem.Write(w, r, http.StatusUnauthorized, "Invalid token")
+				return
+			}
 
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			user, err := users.FindByID(r.Context(), claims.UserID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to load session", "error", err)
+				problem.Write(w, r, http.StatusInternalServerError, "Failed to load session")
+				return
+			}
+			if user == nil || user.TokenVersion != claims.TokenVersion {
+				problem.Write(w, r, http.StatusUnauthorized, "Session expired")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+			ctx = context.WithValue(ctx, "role", user.Role)
+			ctx = context.WithValue(ctx, "mfa", claims.MFA)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 func AdminOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		role := r.Context().Value("role").(string)
 		if role != "admin" {
-			http.Error(w, "Admin access required", http.StatusForbidden)
+			problem.Write(w, r, http.StatusForbidden, "Admin access required")
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireMFA rejects sessions that did not pass a second factor when the user's
+// role is one of the roles for which two-factor authentication is enforced.
+func RequireMFA(roles []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("role").(string)
+			mfa, _ := r.Context().Value("mfa").(bool)
+			if !mfa && slices.Contains(roles, role) {
+				problem.Write(w, r, http.StatusForbidden, "Two-factor authentication required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"inventory-service/utils"
+	"net/http"
+)
+
+// ClientIP resolves the originating address of each request, believing the
+// forwarding headers of trusted proxies only, so that clients cannot spoof it to
+// escape the per-IP login lockout. Handlers read it with utils.ClientIP.
+func ClientIP(proxies utils.TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(utils.WithClientIP(r.Context(), proxies.ClientIP(r))))
+		})
+	}
+}
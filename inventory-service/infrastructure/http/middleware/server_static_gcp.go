@@ -2,7 +2,7 @@ package middleware
 
 import (
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -20,7 +20,7 @@ func ServeFromGCS(bucketName string) http.HandlerFunc {
 		resp, err := http.Get(gcsURL)
 		if err != nil || resp.StatusCode != http.StatusOK {
 			// fallback to index.html
-			log.Printf("Falling back to index.html for path: %s", path)
+			slog.DebugContext(r.Context(), "Falling back to index.html", "path", path)
 			indexResp, err := http.Get("https://storage.googleapis.com/" + bucketName + "/index.html")
 			if err != nil || indexResp.StatusCode != http.StatusOK {
 				http.Error(w, "404 Not Found", http.StatusNotFound)
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"inventory-service/infrastructure/config"
+	"inventory-service/infrastructure/http/problem"
 	"net/http"
 )
 
@@ -11,22 +13,20 @@ func ServiceAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 			// Parse the API key from the request header
 			apiKey := r.Header.Get("X-API-Key")
 			if apiKey == "" {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte(`{"error": "API key missing"}`))
+				problem.Write(w, r, http.StatusUnauthorized, "API key missing")
 				return
 			}
 
 			// Validate the API key against the configured value
 			if apiKey != cfg.ServiceAPIKey {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusUnauthorized)
-				w.Write([]byte(`{"error": "Invalid API key"}`))
+				problem.Write(w, r, http.StatusUnauthorized, "Invalid API key")
 				return
 			}
 
-			// API key is valid, proceed to the next handler
-			next.ServeHTTP(w, r)
+			// API key is valid, proceed to the next handler as the service principal
+			ctx := context.WithValue(r.Context(), "user_id", "service")
+			ctx = context.WithValue(ctx, "role", "service")
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
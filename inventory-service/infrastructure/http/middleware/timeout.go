@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Timeout bounds each request by a deadline, after which the MongoDB and Redis calls
+// made with its context are aborted. The timeout of a route is looked up in routes by
+// method and path template, e.g. "POST /inventory/api/products", then by template
+// alone, and is otherwise fallback. Like Metrics, it must be installed with
+// Router.Use.
+func Timeout(fallback time.Duration, routes map[string]time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := fallback
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					if t, ok := routes[r.Method+" "+template]; ok {
+						timeout = t
+					} else if t, ok := routes[template]; ok {
+						timeout = t
+					}
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"inventory-service/application"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/cache"
+	"inventory-service/infrastructure/config"
+	"inventory-service/infrastructure/db"
+	"inventory-service/infrastructure/mail"
+	"inventory-service/infrastructure/messaging"
+	"inventory-service/infrastructure/repository"
+	"inventory-service/infrastructure/services"
+)
+
+// Dependencies holds the repositories, services and usecases of the service. They
+// are built once and shared by the HTTP routes and the background workers started by
+// main, so that both use the same instances.
+type Dependencies struct {
+	UserRepo      domain.UserRepository
+	StockRepo     domain.StockRepository
+	OutboxRepo    domain.OutboxRepository
+	EmailService  services.EmailService
+	CloudinarySvc *services.CloudinaryService
+
+	ProductUsecase       *application.ProductUsecase
+	UserUsecase          *application.UserUsecase
+	CategoryUsecase      *application.CategoryUsecase
+	UserInfoUsecase      *application.UserInfoUsecase
+	StockUsecase         *application.StockUsecase
+	AccountUsecase       *application.AccountUsecase
+	InvitationUsecase    *application.InvitationUsecase
+	AuditUsecase         *application.AuditUsecase
+	EmailTemplateUsecase *application.EmailTemplateUsecase
+	EmailDeliveryUsecase *application.EmailDeliveryUsecase
+}
+
+func NewDependencies(mongoClient *db.MongoClient, cfg *config.Config, redisClient *cache.RedisClient) *Dependencies {
+	productRepo := repository.NewProductRepository(mongoClient, "inventory_db", "products", redisClient)
+	userRepo := repository.NewUserRepository(mongoClient, "inventory_db", "users")
+	categoryRepo := repository.NewCategoryRepository(mongoClient, "inventory_db", "categories", redisClient)
+	userInfoRepo := repository.NewUserInfoRepository(mongoClient, "inventory_db", "users")
+	stockRepo := repository.NewStockRepository(mongoClient, "inventory_db", "products", redisClient)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(redisClient)
+	invitationRepo := repository.NewInvitationRepository(mongoClient, "inventory_db", "invitations")
+	auditRepo := repository.NewAuditRepository(mongoClient, "inventory_db", "audit_log")
+	emailTemplateRepo := repository.NewEmailTemplateRepository(mongoClient, "inventory_db", "email_templates")
+	emailDeliveryRepo := repository.NewEmailDeliveryRepository(mongoClient, "inventory_db", "email_deliveries")
+	outboxRepo := repository.NewOutboxRepository(mongoClient, "inventory_db", "outbox")
+	reservationRepo := repository.NewStockReservationRepository(mongoClient, "inventory_db", "stock_reservations")
+	processedEventRepo := repository.NewProcessedEventRepository(mongoClient, "inventory_db", "processed_events")
+	transactor := db.NewTransactor(mongoClient)
+
+	templateRenderer := mail.NewServiceRenderer(emailTemplateRepo, cfg.EmailTemplateDir)
+
+	emailSvc := services.NewEmailService(cfg, outboxRepo, templateRenderer)
+	eventPublisher := messaging.NewEventPublisher(outboxRepo, cfg.KafkaInventoryEventsTopic)
+
+	return &Dependencies{
+		UserRepo:      userRepo,
+		StockRepo:     stockRepo,
+		OutboxRepo:    outboxRepo,
+		EmailService:  emailSvc,
+		CloudinarySvc: services.NewCloudinaryService(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret),
+
+		ProductUsecase: application.NewProductUsecase(productRepo, transactor, eventPublisher),
+		UserUsecase: application.NewUserUsecase(userRepo, loginAttemptRepo, transactor, emailSvc, application.MFAPolicy{
+			Issuer:        cfg.MFAIssuer,
+			RequiredRoles: cfg.MFARequiredRoles,
+		}),
+		CategoryUsecase: application.NewCategoryUsecase(categoryRepo, transactor, eventPublisher),
+		UserInfoUsecase: application.NewUserInfoUsecase(userInfoRepo, loginAttemptRepo),
+		StockUsecase: application.NewStockUsecase(stockRepo, productRepo, reservationRepo, processedEventRepo, transactor, eventPublisher, emailSvc, application.LowStockAlert{
+			Threshold:  cfg.LowStockThreshold,
+			Recipients: cfg.LowStockRecipients,
+		}),
+		AccountUsecase:       application.NewAccountUsecase(userRepo, loginAttemptRepo, auditRepo, invitationRepo, emailDeliveryRepo, outboxRepo, transactor, emailSvc),
+		InvitationUsecase:    application.NewInvitationUsecase(invitationRepo, userRepo, transactor, emailSvc),
+		AuditUsecase:         application.NewAuditUsecase(auditRepo, outboxRepo, transactor, cfg.KafkaAuditTopic),
+		EmailTemplateUsecase: application.NewEmailTemplateUsecase(emailTemplateRepo, templateRenderer),
+		EmailDeliveryUsecase: application.NewEmailDeliveryUsecase(emailDeliveryRepo),
+	}
+}
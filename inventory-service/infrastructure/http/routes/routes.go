@@ -1,16 +1,13 @@
 package routes
 
 import (
-	"inventory-service/application"
-	"inventory-service/infrastructure/cache"
+	"context"
 	"inventory-service/infrastructure/config"
-	"inventory-service/infrastructure/db"
+	"inventory-service/infrastructure/health"
 	"inventory-service/infrastructure/http/handlers"
 	"inventory-service/infrastructure/http/middleware"
-	"inventory-service/infrastructure/messaging"
-	"inventory-service/infrastructure/repository"
-	"inventory-service/infrastructure/services"
-	"log"
+	"inventory-service/infrastructure/metrics"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -24,7 +21,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		// Handle preflight OPTIONS request
 		if r.Method == "OPTIONS" {
@@ -37,66 +35,117 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func SetupRouter(mongoClient *db.MongoClient, cfg *config.Config, kafkaProducer *messaging.KafkaProducer, redisClient *cache.RedisClient) *mux.Router {
+func SetupRouter(deps *Dependencies, cfg *config.Config, checker *health.Checker) *mux.Router {
 	r := mux.NewRouter()
 
 	// Apply CORS middleware to the main router
 	r.Use(corsMiddleware)
+	r.Use(middleware.RequestID)
+	r.Use(middleware.ClientIP(cfg.Proxies()))
+	r.Use(middleware.Metrics)
+	r.Use(middleware.Tracing)
+	r.Use(middleware.Timeout(cfg.RequestTimeout, cfg.TimeoutsByRoute()))
+
+	// Probed by Kubernetes, advertised to Eureka and scraped by Prometheus, outside
+	// the API prefix and without authentication
+	healthHandler := handlers.NewHealthHandler(checker)
+	r.HandleFunc("/health/live", healthHandler.Live).Methods("GET")
+	r.HandleFunc("/health/ready", healthHandler.Ready).Methods("GET")
+	r.HandleFunc("/info", healthHandler.Info).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
 
 	apiRouter := r.PathPrefix("/inventory/api").Subrouter()
-	log.Println("API router initialized with prefix /inventory/api")
-
-	// Rest of your existing code remains the same...
-	productRepo := repository.NewProductRepository(mongoClient, "inventory_db", "products", redisClient)
-	userRepo := repository.NewUserRepository(mongoClient, "inventory_db", "users")
-	categoryRepo := repository.NewCategoryRepository(mongoClient, "inventory_db", "categories", redisClient)
-	userInfoRepo := repository.NewUserInfoRepository(mongoClient, "inventory_db", "users")
-	stockRepo := repository.NewStockRepository(mongoClient, "inventory_db", "products", redisClient)
-
-	cloudinarySvc := services.NewCloudinaryService(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
-	emailSvc := services.NewEmailService(cfg, kafkaProducer)
-
-	productUsecase := application.NewProductUsecase(productRepo)
-	userUsecase := application.NewUserUsecase(userRepo, emailSvc)
-	categoryUsecase := application.NewCategoryUsecase(categoryRepo)
-	userInfoUsecase := application.NewUserInfoUsecase(userInfoRepo)
-	stockUsecase := application.NewStockUsecase(stockRepo)
-
-	productHandler := handlers.NewProductHandler(productUsecase, cloudinarySvc)
-	userHandler := handlers.NewUserHandler(userUsecase)
-	categoryHandler := handlers.NewCategoryHandler(categoryUsecase)
-	userInfoHandler := handlers.NewUserInfoHandler(userInfoUsecase)
-	stockHandler := handlers.NewStockHandler(stockUsecase)
+	slog.Info("API router initialized", "prefix", "/inventory/api")
+
+	productHandler := handlers.NewProductHandler(deps.ProductUsecase, deps.CloudinarySvc)
+	userHandler := handlers.NewUserHandler(deps.UserUsecase)
+	categoryHandler := handlers.NewCategoryHandler(deps.CategoryUsecase)
+	userInfoHandler := handlers.NewUserInfoHandler(deps.UserInfoUsecase)
+	stockHandler := handlers.NewStockHandler(deps.StockUsecase)
+	accountHandler := handlers.NewAccountHandler(deps.AccountUsecase)
+	invitationHandler := handlers.NewInvitationHandler(deps.InvitationUsecase)
+	auditHandler := handlers.NewAuditHandler(deps.AuditUsecase)
+	emailTemplateHandler := handlers.NewEmailTemplateHandler(deps.EmailTemplateUsecase)
+	emailDeliveryHandler := handlers.NewEmailDeliveryHandler(deps.EmailDeliveryUsecase)
+
+	// Every mutating authenticated route is wrapped so that it lands in the audit log
+	audited := func(action string, target middleware.AuditTarget, h http.HandlerFunc) http.Handler {
+		return middleware.Audit(deps.AuditUsecase, action, target)(h)
+	}
+	productTarget := middleware.AuditTarget{Type: "product", Load: func(ctx context.Context, id string) (interface{}, error) {
+		return deps.ProductUsecase.GetByID(ctx, id)
+	}}
+	categoryTarget := middleware.AuditTarget{Type: "category", Load: func(ctx context.Context, id string) (interface{}, error) {
+		return deps.CategoryUsecase.GetByID(ctx, id)
+	}}
+	userTarget := middleware.AuditTarget{Type: "user", Load: func(ctx context.Context, id string) (interface{}, error) {
+		return deps.UserInfoUsecase.GetByID(ctx, id)
+	}}
+	selfTarget := middleware.AuditTarget{Type: "user", ID: middleware.SelfTarget, Load: userTarget.Load}
+	// Without a snapshot, which would keep the personal data the deletion erases
+	deletedSelfTarget := middleware.AuditTarget{Type: "user", ID: middleware.SelfTarget}
+	invitationTarget := middleware.AuditTarget{Type: "invitation"}
+	emailTemplateTarget := middleware.AuditTarget{Type: "email_template", ID: func(r *http.Request) string {
+		return mux.Vars(r)["name"]
+	}}
 
 	apiRouter.HandleFunc("/users/register", userHandler.Register).Methods("POST")
 	apiRouter.HandleFunc("/users/login", userHandler.Login).Methods("POST")
+	apiRouter.HandleFunc("/users/login/mfa", userHandler.VerifyMFA).Methods("POST")
+	apiRouter.HandleFunc("/users/verify/resend", userHandler.ResendVerification).Methods("POST")
 	apiRouter.HandleFunc("/users/verify/{token}", userHandler.VerifyEmail).Methods("GET")
 	apiRouter.HandleFunc("/users/password/reset", userHandler.RequestPasswordReset).Methods("POST")
 	apiRouter.HandleFunc("/users/password/reset/{token}", userHandler.ResetPassword).Methods("POST")
+	apiRouter.HandleFunc("/users/email/confirm/{token}", accountHandler.ConfirmEmailChange).Methods("GET")
+	apiRouter.HandleFunc("/invitations/accept", invitationHandler.Accept).Methods("POST")
 	apiRouter.HandleFunc("/products", productHandler.GetAllProducts).Methods("GET")
 	apiRouter.HandleFunc("/products/{id}", productHandler.GetProduct).Methods("GET")
 	apiRouter.HandleFunc("/categories", categoryHandler.GetAllCategories).Methods("GET")
 	apiRouter.HandleFunc("/categories/{id}", categoryHandler.GetCategory).Methods("GET")
 
 	authRouter := apiRouter.PathPrefix("/").Subrouter()
-	authRouter.Use(middleware.AuthMiddleware)
-	authRouter.HandleFunc("/products", productHandler.CreateProduct).Methods("POST")
-	authRouter.HandleFunc("/categories", categoryHandler.CreateCategory).Methods("POST")
-
-	adminRouter := authRouter.PathPrefix("/").Subrouter()
+	authRouter.Use(middleware.AuthMiddleware(deps.UserRepo))
+	// Enrolment stays reachable for users whose role requires a second factor they do not have yet
+	authRouter.Handle("/users/me/mfa/enroll", audited("user.mfa_enroll", selfTarget, userHandler.BeginMFAEnrollment)).Methods("POST")
+	authRouter.Handle("/users/me/mfa/confirm", audited("user.mfa_confirm", selfTarget, userHandler.ConfirmMFAEnrollment)).Methods("POST")
+
+	mfaRouter := authRouter.PathPrefix("/").Subrouter()
+	mfaRouter.Use(middleware.RequireMFA(cfg.MFARequiredRoles))
+	mfaRouter.Handle("/users/me/mfa/disable", audited("user.mfa_disable", selfTarget, userHandler.DisableMFA)).Methods("POST")
+	// Self-service routes must be registered before the admin /users/{id} routes
+	mfaRouter.HandleFunc("/users/me", accountHandler.GetProfile).Methods("GET")
+	mfaRouter.Handle("/users/me", audited("user.update_profile", selfTarget, accountHandler.UpdateProfile)).Methods("PUT")
+	mfaRouter.Handle("/users/me", audited("user.delete_account", deletedSelfTarget, accountHandler.DeleteAccount)).Methods("DELETE")
+	mfaRouter.Handle("/users/me/password", audited("user.change_password", selfTarget, accountHandler.ChangePassword)).Methods("PUT")
+	mfaRouter.Handle("/users/me/email", audited("user.request_email_change", selfTarget, accountHandler.RequestEmailChange)).Methods("POST")
+	mfaRouter.HandleFunc("/users/me/export", accountHandler.Export).Methods("GET")
+	mfaRouter.Handle("/products", audited("product.create", productTarget, productHandler.CreateProduct)).Methods("POST")
+	mfaRouter.Handle("/categories", audited("category.create", categoryTarget, categoryHandler.CreateCategory)).Methods("POST")
+
+	adminRouter := mfaRouter.PathPrefix("/").Subrouter()
 	adminRouter.Use(middleware.AdminOnly)
-	adminRouter.HandleFunc("/products/{id}", productHandler.UpdateProduct).Methods("PUT")
-	adminRouter.HandleFunc("/products/{id}", productHandler.DeleteProduct).Methods("DELETE")
-	adminRouter.HandleFunc("/categories/{id}", categoryHandler.UpdateCategory).Methods("PUT")
-	adminRouter.HandleFunc("/categories/{id}", categoryHandler.DeleteCategory).Methods("DELETE")
+	adminRouter.Handle("/products/{id}", audited("product.update", productTarget, productHandler.UpdateProduct)).Methods("PUT")
+	adminRouter.Handle("/products/{id}", audited("product.delete", productTarget, productHandler.DeleteProduct)).Methods("DELETE")
+	adminRouter.Handle("/categories/{id}", audited("category.update", categoryTarget, categoryHandler.UpdateCategory)).Methods("PUT")
+	adminRouter.Handle("/categories/{id}", audited("category.delete", categoryTarget, categoryHandler.DeleteCategory)).Methods("DELETE")
 	adminRouter.HandleFunc("/users", userInfoHandler.GetAll).Methods("GET")
 	adminRouter.HandleFunc("/users/{id}", userInfoHandler.GetByID).Methods("GET")
-	adminRouter.HandleFunc("/users/{id}", userInfoHandler.Update).Methods("PUT")
-	adminRouter.HandleFunc("/users/{id}", userInfoHandler.Delete).Methods("DELETE")
+	adminRouter.Handle("/users/{id}", audited("user.update", userTarget, userInfoHandler.Update)).Methods("PUT")
+	adminRouter.Handle("/users/{id}", audited("user.delete", userTarget, userInfoHandler.Delete)).Methods("DELETE")
+	adminRouter.Handle("/users/{id}/unlock", audited("user.unlock", userTarget, userInfoHandler.Unlock)).Methods("POST")
+	adminRouter.Handle("/invitations", audited("invitation.create", invitationTarget, invitationHandler.Create)).Methods("POST")
+	adminRouter.HandleFunc("/invitations", invitationHandler.GetAll).Methods("GET")
+	adminRouter.Handle("/invitations/{id}", audited("invitation.revoke", invitationTarget, invitationHandler.Revoke)).Methods("DELETE")
+	adminRouter.HandleFunc("/audit-logs", auditHandler.GetAll).Methods("GET")
+	adminRouter.HandleFunc("/email-deliveries", emailDeliveryHandler.GetAll).Methods("GET")
+	adminRouter.HandleFunc("/email-templates", emailTemplateHandler.GetAll).Methods("GET")
+	adminRouter.HandleFunc("/email-templates/{name}/preview", emailTemplateHandler.Preview).Methods("GET")
+	adminRouter.Handle("/email-templates/{name}", audited("email_template.update", emailTemplateTarget, emailTemplateHandler.Save)).Methods("PUT")
+	adminRouter.Handle("/email-templates/{name}", audited("email_template.reset", emailTemplateTarget, emailTemplateHandler.Reset)).Methods("DELETE")
 
 	serviceRouter := apiRouter.PathPrefix("/").Subrouter()
 	serviceRouter.Use(middleware.ServiceAuthMiddleware(cfg))
-	serviceRouter.HandleFunc("/stocks/bulk-update", stockHandler.BulkUpdateStock).Methods("POST")
+	serviceRouter.Handle("/stocks/bulk-update", audited("stock.bulk_update", middleware.AuditTarget{Type: "stock"}, stockHandler.BulkUpdateStock)).Methods("POST")
 
 	fs := http.FileServer(http.Dir("cmd/dist"))
 	r.PathPrefix("/").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2,6 +2,8 @@ package lock
 
 import (
 	"context"
+	"inventory-service/infrastructure/metrics"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -25,12 +27,24 @@ func NewDistributedLock(client *redis.Client, key, value string, expiration time
 
 func (l *DistributedLock) Acquire(ctx context.Context) (bool, error) {
 	success, err := l.client.SetNX(ctx, l.key, l.value, l.expiration).Result()
+	if err != nil || !success {
+		metrics.LockFailed(l.name(), err)
+	}
 	if err != nil {
 		return false, err
 	}
 	return success, nil
 }
 
+// name is the key without its last segment, e.g. lock:product:update for
+// lock:product:update:<id>, so that metrics are per kind of lock.
+func (l *DistributedLock) name() string {
+	if i := strings.LastIndex(l.key, ":"); i > 0 {
+		return l.key[:i]
+	}
+	return l.key
+}
+
 func (l *DistributedLock) Release(ctx context.Context) error {
 	script := `
 		if redis.call("get", KEYS[1]) == ARGV[1] then
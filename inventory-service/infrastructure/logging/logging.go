@@ -0,0 +1,140 @@
+// Package logging builds the structured logger of the service, installed as the
+// slog default, and carries log attributes in contexts: the ID of the request being
+// served, or e.g. the Kafka message being handled. Code logs with the slog
+// functions taking a context, e.g. slog.ErrorContext, to get them.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats selectable with LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+const redacted = "[redacted]"
+
+// sensitiveKeys are substrings of attribute keys whose values are never logged.
+var sensitiveKeys = []string{"password", "secret", "token", "api_key", "apikey", "authorization", "cookie", "mongo_url", "redis_url", "otp", "recovery_code"}
+
+// Patterns of secrets that end up inside other values, e.g. an error message
+// quoting a connection string.
+var (
+	urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s:@]+:[^/\s@]+@`)
+	bearerToken    = regexp.MustCompile(`(?i)(bearer\s+)[^\s"]+`)
+	jwt            = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+// New returns a logger writing to w at level (debug, info, warn or error) in format
+// (json or text). Values of sensitive attributes, and secrets found inside any
+// string, are redacted; records logged with a context carry the attributes of the
+// context and the IDs of its trace and span.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		// Errors and other values are logged as text; scrub that text
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+	}
+	return a
+}
+
+// Scrub replaces the credentials of URLs, bearer tokens and JWTs found in s.
+func Scrub(s string) string {
+	s = urlCredentials.ReplaceAllString(s, "${1}"+redacted+"@")
+	s = bearerToken.ReplaceAllString(s, "${1}"+redacted)
+	return jwt.ReplaceAllString(s, redacted)
+}
+
+// contextHandler adds the attributes and the trace of the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type contextKey int
+
+const (
+	attrsKey contextKey = iota
+	requestIDKey
+)
+
+// With returns ctx carrying attributes, given as for slog.Logger.With, for every
+// record logged with it.
+func With(ctx context.Context, args ...any) context.Context {
+	record := slog.Record{}
+	record.Add(args...)
+	existing, _ := ctx.Value(attrsKey).([]slog.Attr)
+	attrs := append([]slog.Attr(nil), existing...)
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey, attrs)
+}
+
+// WithRequestID returns ctx carrying the ID of the request being served, which is
+// also logged.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return With(context.WithValue(ctx, requestIDKey, id), "request_id", id)
+}
+
+// RequestID returns the ID of the request being served, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
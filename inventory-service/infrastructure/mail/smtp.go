@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP connection security modes accepted by SMTP_SECURITY.
+const (
+	SecurityNone     = "none"     // Plain connection; only sensible for a local relay
+	SecurityStartTLS = "starttls" // Upgrade with STARTTLS, usually on port 587
+	SecurityTLS      = "tls"      // Implicit TLS from the start, usually on port 465
+)
+
+// smtpTimeout bounds a whole delivery, from dialing to QUIT.
+const smtpTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Authentication is skipped when empty
+	Password string
+	Security string
+}
+
+type SMTPTransport struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPTransport(cfg SMTPConfig) (*SMTPTransport, error) {
+	switch cfg.Security {
+	case SecurityNone, SecurityStartTLS, SecurityTLS:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security %q, expected none, starttls or tls", cfg.Security)
+	}
+	return &SMTPTransport{cfg: cfg}, nil
+}
+
+func (t *SMTPTransport) Send(ctx context.Context, msg *Message) error {
+	raw, err := BuildMessage(msg.From, msg.To, msg.Subject, msg.Text, msg.HTML)
+	if err != nil {
+		return err
+	}
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.cfg.Security == SecurityStartTLS {
+		// Never fall back to plain text, or the credentials would be sent in the clear
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: t.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if t.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(msg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (t *SMTPTransport) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	if t.cfg.Security == SecurityTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: t.cfg.Host}}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
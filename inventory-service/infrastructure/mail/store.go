@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"inventory-service/domain"
+	"io/fs"
+	"os"
+)
+
+// ErrTemplateNotFound is returned by a TemplateStore that has no source for the
+// requested template and locale.
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// TemplateStore supplies template sources. Lookups are exact: locale fallback is
+// handled by the Renderer, with "" meaning the locale-neutral variant.
+type TemplateStore interface {
+	Lookup(ctx context.Context, name, locale string) (string, error)
+}
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// FSStore reads templates from a file system laid out as <name>.tmpl for the
+// default variant and <name>.<locale>.tmpl for localized ones.
+type FSStore struct {
+	fsys fs.FS
+}
+
+// DefaultStore serves the templates compiled into the binary.
+func DefaultStore() *FSStore {
+	sub, _ := fs.Sub(defaultTemplates, "templates")
+	return &FSStore{fsys: sub}
+}
+
+// NewDirStore serves templates from a directory on disk, so that deployments can
+// override the defaults without rebuilding.
+func NewDirStore(dir string) *FSStore {
+	return &FSStore{fsys: os.DirFS(dir)}
+}
+
+func (s *FSStore) Lookup(ctx context.Context, name, locale string) (string, error) {
+	file := name + ".tmpl"
+	if locale != "" {
+		file = name + "." + locale + ".tmpl"
+	}
+	data, err := fs.ReadFile(s.fsys, file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrTemplateNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MongoStore serves templates saved through the admin API.
+type MongoStore struct {
+	repo domain.EmailTemplateRepository
+}
+
+func NewMongoStore(repo domain.EmailTemplateRepository) *MongoStore {
+	return &MongoStore{repo: repo}
+}
+
+func (s *MongoStore) Lookup(ctx context.Context, name, locale string) (string, error) {
+	tmpl, err := s.repo.Find(ctx, name, locale)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return "", ErrTemplateNotFound
+	}
+	return tmpl.Source, nil
+}
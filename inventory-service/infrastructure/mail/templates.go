@@ -0,0 +1,197 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"inventory-service/domain"
+	"log/slog"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Names of the templates the service sends.
+const (
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "password_reset"
+	TemplateEmailChange   = "email_change"
+	TemplateInvitation    = "invitation"
+	TemplateLowStock      = "low_stock"
+
+	// layoutTemplate wraps the "content" block of every template in the HTML part
+	layoutTemplate = "layout"
+)
+
+var ErrUnknownTemplate = domain.NewNotFoundError("unknown email template")
+
+// SampleData is what each template is rendered with in previews and when an edited
+// template is validated. It lists every field the service passes to the template.
+var SampleData = map[string]map[string]interface{}{
+	TemplateVerification: {
+		"Email": "jane.doe@example.com",
+		"Link":  "https://inventory.example.com/inventory/api/users/verify/sample-token",
+	},
+	TemplatePasswordReset: {
+		"Email": "jane.doe@example.com",
+		"Link":  "https://inventory.example.com/reset-password/sample-token",
+	},
+	TemplateEmailChange: {
+		"Email": "jane.new@example.com",
+		"Link":  "https://inventory.example.com/inventory/api/users/email/confirm/sample-token",
+	},
+	TemplateInvitation: {
+		"Email": "jane.doe@example.com",
+		"Role":  "admin",
+		"Link":  "https://inventory.example.com/invitations/accept/sample-token",
+	},
+	TemplateLowStock: {
+		"ProductID":   "665f1c2e9b1d4a0012345678",
+		"ProductName": "Wireless Mouse",
+		"Category":    "Accessories",
+		"Stock":       3,
+		"Threshold":   5,
+	},
+}
+
+// Names returns the names of all templates in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(SampleData))
+	for name := range SampleData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rendered is a template rendered for one recipient. HTML is empty when the
+// template has no HTML part.
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Renderer looks templates up in its stores, most specific locale first and, for
+// each locale, in store order; the first store holding a variant wins.
+type Renderer struct {
+	stores []TemplateStore
+}
+
+func NewRenderer(stores ...TemplateStore) *Renderer {
+	return &Renderer{stores: stores}
+}
+
+// NewServiceRenderer returns the renderer the service uses: templates are looked up
+// in Mongo first, then in dir if set, then in the built-in defaults.
+func NewServiceRenderer(repo domain.EmailTemplateRepository, dir string) *Renderer {
+	stores := []TemplateStore{NewMongoStore(repo)}
+	if dir != "" {
+		stores = append(stores, NewDirStore(dir))
+	}
+	return NewRenderer(append(stores, DefaultStore())...)
+}
+
+// Render renders the named template for the given locale, falling back from e.g.
+// "pt-BR" to "pt" and then to the default variant.
+func (r *Renderer) Render(ctx context.Context, name, locale string, data map[string]interface{}) (*Rendered, error) {
+	if _, ok := SampleData[name]; !ok {
+		return nil, ErrUnknownTemplate
+	}
+	source, err := r.lookup(ctx, name, locale)
+	if err != nil {
+		return nil, fmt.Errorf("email template %s: %w", name, err)
+	}
+	return r.execute(ctx, locale, source, data)
+}
+
+// Preview renders the named template with its sample data.
+func (r *Renderer) Preview(ctx context.Context, name, locale string) (*Rendered, error) {
+	return r.Render(ctx, name, locale, SampleData[name])
+}
+
+// Validate renders source as a replacement for the named template with the sample
+// data, reporting any syntax error or missing field.
+func (r *Renderer) Validate(ctx context.Context, name, locale, source string) (*Rendered, error) {
+	if _, ok := SampleData[name]; !ok {
+		return nil, ErrUnknownTemplate
+	}
+	return r.execute(ctx, locale, source, SampleData[name])
+}
+
+func (r *Renderer) lookup(ctx context.Context, name, locale string) (string, error) {
+	for _, candidate := range localeCandidates(locale) {
+		for _, store := range r.stores {
+			source, err := store.Lookup(ctx, name, candidate)
+			if errors.Is(err, ErrTemplateNotFound) {
+				continue
+			}
+			if err != nil {
+				// An unavailable override store must not stop emails from going out
+				slog.WarnContext(ctx, "Failed to look up email template", "template", name, "locale", candidate, "error", err)
+				continue
+			}
+			return source, nil
+		}
+	}
+	return "", ErrTemplateNotFound
+}
+
+func (r *Renderer) execute(ctx context.Context, locale, source string, data map[string]interface{}) (*Rendered, error) {
+	layout, err := r.lookup(ctx, layoutTemplate, locale)
+	if err != nil {
+		return nil, fmt.Errorf("email layout: %w", err)
+	}
+
+	// Subject and text are plain text; only the HTML part gets contextual escaping
+	textTmpl := texttemplate.New("email").Option("missingkey=error")
+	htmlTmpl := htmltemplate.New("email").Option("missingkey=error")
+	for _, src := range []string{layout, source} {
+		if _, err := textTmpl.Parse(src); err != nil {
+			return nil, err
+		}
+		if _, err := htmlTmpl.Parse(src); err != nil {
+			return nil, err
+		}
+	}
+	for _, block := range []string{"subject", "text"} {
+		if textTmpl.Lookup(block) == nil {
+			return nil, fmt.Errorf("email template does not define %q", block)
+		}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := textTmpl.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, err
+	}
+	if htmlTmpl.Lookup("html") != nil {
+		if err := htmlTmpl.ExecuteTemplate(&html, "html", data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Rendered{
+		// Subjects end up in a header, so they must stay on one line
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    strings.TrimSpace(html.String()),
+	}, nil
+}
+
+// localeCandidates lists the variants to try for a locale, e.g. "pt-BR", "pt", "".
+func localeCandidates(locale string) []string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "" {
+		return []string{""}
+	}
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	return append(candidates, "")
+}
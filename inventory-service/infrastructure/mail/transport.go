@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inventory-service/infrastructure/config"
+	"net/textproto"
+)
+
+// Message is an email ready to be handed to a transport.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string // Empty for a text-only email
+}
+
+// Transport delivers emails.
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Transport names accepted by MAIL_TRANSPORT.
+const (
+	TransportSMTP   = "smtp"
+	TransportFile   = "file"
+	TransportMemory = "memory"
+)
+
+// NewTransport returns the transport selected in the configuration.
+func NewTransport(cfg *config.Config) (Transport, error) {
+	switch cfg.MailTransport {
+	case TransportSMTP:
+		return NewSMTPTransport(SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			Security: cfg.SMTPSecurity,
+		})
+	case TransportFile:
+		return NewFileTransport(cfg.MailOutboxDir)
+	case TransportMemory:
+		return NewMemoryTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q, expected smtp, file or memory", cfg.MailTransport)
+	}
+}
+
+// IsPermanent reports whether a delivery failed in a way that retrying cannot fix,
+// such as a 5xx reply rejecting the recipient. Rejected credentials affect every
+// message rather than this one, so they do not count as permanent.
+func IsPermanent(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500 && reply.Code != 530 && reply.Code != 535
+}
@@ -0,0 +1,164 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// EmailSender delivers a single email.
+type EmailSender func(ctx context.Context, msg EmailMessage) error
+
+// PermanentError marks a delivery failure that retrying cannot fix, such as a
+// rejected recipient. The message goes to the dead-letter topic straight away.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// RetryPolicy bounds the delivery attempts made for one message before it is
+// dead-lettered.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration // Delay after the first failure, doubled after each further one
+	MaxBackoff  time.Duration
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff << (attempt - 1)
+	if d <= 0 || d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// Headers set on messages in the dead-letter topic.
+const (
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderFailedAt          = "x-failed-at"
+	HeaderReplayedAt        = "x-replayed-at" // Set when a dead letter is moved back to its topic
+)
+
+// EmailConsumer delivers the emails queued on the email topic. A message is only
+// acknowledged once it has been sent or moved to the dead-letter topic, so an email
+// is never dropped silently.
+type EmailConsumer struct {
+	publisher  domain.Publisher
+	deliveries domain.EmailDeliveryRepository
+	send       EmailSender
+	dlqTopic   string
+	retry      RetryPolicy
+}
+
+func NewEmailConsumer(publisher domain.Publisher, deliveries domain.EmailDeliveryRepository, send EmailSender, dlqTopic string, retry RetryPolicy) *EmailConsumer {
+	return &EmailConsumer{
+		publisher:  publisher,
+		deliveries: deliveries,
+		send:       send,
+		dlqTopic:   dlqTopic,
+		retry:      retry,
+	}
+}
+
+// Run consumes topic as part of group until ctx is cancelled.
+func (c *EmailConsumer) Run(ctx context.Context, subscriber domain.Subscriber, group, topic string) error {
+	return subscriber.Subscribe(ctx, group, topic, c.handle)
+}
+
+func (c *EmailConsumer) handle(ctx context.Context, msg *domain.Message) error {
+	var email EmailMessage
+	if err := DecodeMessage(msg, SchemaEmail, &email); err != nil {
+		slog.WarnContext(ctx, "Dead-lettering malformed message", "error", err)
+		return deadLetter(ctx, c.publisher, c.dlqTopic, msg, 0, err)
+	}
+	if email.ID == "" {
+		// Messages queued before IDs were assigned
+		email.ID = fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset)
+	}
+
+	delivery, err := c.deliveries.FindByMessageID(ctx, email.ID)
+	if err != nil {
+		return err
+	}
+	if delivery != nil && delivery.Status == models.EmailDeliverySent {
+		slog.InfoContext(ctx, "Skipping email, already sent", "email_id", email.ID)
+		return nil
+	}
+	if delivery == nil {
+		delivery = &models.EmailDelivery{
+			MessageID: email.ID,
+			Type:      email.Type,
+			To:        email.To,
+			Subject:   email.Subject,
+			CreatedAt: time.Now(),
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, email)
+		now := time.Now()
+		delivery.Attempts++
+		delivery.UpdatedAt = now
+		if err == nil {
+			delivery.Status = models.EmailDeliverySent
+			delivery.LastError = ""
+			delivery.SentAt = &now
+			c.record(ctx, delivery)
+			slog.InfoContext(ctx, "Email sent", "email_id", email.ID, "email_type", email.Type)
+			return nil
+		}
+
+		delivery.LastError = err.Error()
+		var permanent *PermanentError
+		if errors.As(err, &permanent) || attempt >= c.retry.MaxAttempts {
+			slog.ErrorContext(ctx, "Dead-lettering email", "email_id", email.ID, "attempts", attempt, "error", err)
+			if err := deadLetter(ctx, c.publisher, c.dlqTopic, msg, attempt, err); err != nil {
+				return err
+			}
+			delivery.Status = models.EmailDeliveryDeadLettered
+			c.record(ctx, delivery)
+			return nil
+		}
+
+		delivery.Status = models.EmailDeliveryRetrying
+		c.record(ctx, delivery)
+		slog.WarnContext(ctx, "Failed to send email", "email_id", email.ID, "attempt", attempt, "max_attempts", c.retry.MaxAttempts, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.retry.delay(attempt)):
+		}
+	}
+}
+
+// record saves the delivery status. A failure is only logged: by now the email has
+// been sent or dead-lettered, and redelivering it would only cause a duplicate.
+func (c *EmailConsumer) record(ctx context.Context, delivery *models.EmailDelivery) {
+	if err := c.deliveries.Save(ctx, delivery); err != nil {
+		slog.ErrorContext(ctx, "Failed to record email delivery status", "email_id", delivery.MessageID, "error", err)
+	}
+}
+
+// deadLetter moves a message that could not be handled to dlqTopic, recording why
+// and where it came from in its headers.
+func deadLetter(ctx context.Context, publisher domain.Publisher, dlqTopic string, msg *domain.Message, attempts int, cause error) error {
+	headers := schemaHeaders(msg)
+	headers[HeaderError] = cause.Error()
+	headers[HeaderAttempts] = strconv.Itoa(attempts)
+	headers[HeaderOriginalTopic] = msg.Topic
+	headers[HeaderOriginalPartition] = strconv.Itoa(int(msg.Partition))
+	headers[HeaderOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	return publisher.Publish(ctx, &domain.Message{Topic: dlqTopic, Key: msg.Key, Value: msg.Value, Headers: headers})
+}
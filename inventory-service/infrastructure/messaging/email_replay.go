@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"context"
+	"inventory-service/domain"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// replayIdleTimeout ends the replay of a partition that has no messages left to replay.
+const replayIdleTimeout = 5 * time.Second
+
+// ReplayDeadLetters moves the messages currently in dlqTopic back to topic so the
+// worker retries them, and returns how many were moved. Messages dead-lettered while
+// the replay runs are left for the next one, so a failing email cannot loop.
+// Reading up to a snapshot needs Kafka's offsets, so this works on Kafka only.
+func ReplayDeadLetters(ctx context.Context, brokers []string, group, dlqTopic, topic string, publisher domain.Publisher) (int, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	// Snapshot where each partition ends now; replay stops there
+	partitions, err := client.Partitions(dlqTopic)
+	if err != nil {
+		return 0, err
+	}
+	end := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		if end[partition], err = client.GetOffset(dlqTopic, partition, sarama.OffsetNewest); err != nil {
+			return 0, err
+		}
+	}
+
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(group, client)
+	if err != nil {
+		return 0, err
+	}
+	defer consumerGroup.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	handler := &replayHandler{publisher: publisher, topic: topic, end: end, done: cancel}
+	for ctx.Err() == nil {
+		if err := consumerGroup.Consume(ctx, []string{dlqTopic}, handler); err != nil {
+			return int(handler.replayed.Load()), err
+		}
+	}
+	return int(handler.replayed.Load()), handler.err()
+}
+
+type replayHandler struct {
+	publisher domain.Publisher
+	topic     string
+	end       map[int32]int64
+	done      context.CancelFunc
+	replayed  atomic.Int64
+	pending   *sync.WaitGroup // Claims of the current session still replaying
+
+	mu     sync.Mutex
+	failed error
+}
+
+func (h *replayHandler) Setup(session sarama.ConsumerGroupSession) error {
+	pending := &sync.WaitGroup{}
+	for _, partitions := range session.Claims() {
+		pending.Add(len(partitions))
+	}
+	h.pending = pending
+	go func() {
+		pending.Wait()
+		h.done()
+	}()
+	return nil
+}
+
+func (h *replayHandler) fail(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failed == nil {
+		h.failed = err
+	}
+}
+
+func (h *replayHandler) err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failed
+}
+
+func (h *replayHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	session.Commit()
+	return nil
+}
+
+func (h *replayHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	defer h.pending.Done()
+	// A failed partition is reported once the others are done; its messages stay put
+	end := h.end[claim.Partition()]
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok || msg.Offset >= end {
+				return nil
+			}
+			headers := schemaHeaders(fromConsumerMessage(msg))
+			headers[HeaderReplayedAt] = time.Now().UTC().Format(time.RFC3339)
+			if err := h.publisher.Publish(session.Context(), &domain.Message{Topic: h.topic, Key: msg.Key, Value: msg.Value, Headers: headers}); err != nil {
+				h.fail(err)
+				return nil
+			}
+			session.MarkMessage(msg, "")
+			h.replayed.Add(1)
+			slog.InfoContext(session.Context(), "Replayed dead letter", "partition", msg.Partition, "offset", msg.Offset)
+			if msg.Offset+1 >= end {
+				return nil
+			}
+		case <-time.After(replayIdleTimeout):
+			return nil
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"inventory-service/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event types published on the inventory events topic.
+const (
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventProductDeleted  = "product.deleted"
+	EventCategoryCreated = "category.created"
+	EventCategoryUpdated = "category.updated"
+	EventCategoryDeleted = "category.deleted"
+	EventStockChanged    = "stock.changed"
+)
+
+// eventSchema gives the aggregate an event type belongs to and the version of its
+// payload. The version is bumped whenever the payload changes incompatibly.
+type eventSchema struct {
+	aggregateType string
+	version       int
+}
+
+var eventSchemas = map[string]eventSchema{
+	EventProductCreated:  {aggregateType: "product", version: 1},
+	EventProductUpdated:  {aggregateType: "product", version: 1},
+	EventProductDeleted:  {aggregateType: "product", version: 1},
+	EventCategoryCreated: {aggregateType: "category", version: 1},
+	EventCategoryUpdated: {aggregateType: "category", version: 1},
+	EventCategoryDeleted: {aggregateType: "category", version: 1},
+	// Stock belongs to the product so that both share one ordered stream per product
+	EventStockChanged: {aggregateType: "product", version: 1},
+}
+
+// EventEnvelope wraps every event published on the inventory events topic.
+type EventEnvelope struct {
+	ID            string      `json:"id"` // Unique per event; consumers deduplicate on it
+	Type          string      `json:"type"`
+	Version       int         `json:"version"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	AggregateType string      `json:"aggregate_type"`
+	AggregateID   string      `json:"aggregate_id"`
+	Payload       interface{} `json:"payload"`
+}
+
+// DeletedPayload is the payload of the *.deleted events.
+type DeletedPayload struct {
+	ID string `json:"id"`
+}
+
+// StockChangedPayload is the payload of stock.changed.
+type StockChangedPayload struct {
+	ProductID string `json:"product_id"`
+	Delta     int    `json:"delta"` // Negative for a decrease
+	Stock     int    `json:"stock"` // Level after the change
+	Reason    string `json:"reason"`
+}
+
+// NewEventEnvelope wraps a new event of the given type, with a fresh ID.
+func NewEventEnvelope(eventType, aggregateID string, payload interface{}) (*EventEnvelope, error) {
+	schema, ok := eventSchemas[eventType]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	return &EventEnvelope{
+		ID:            primitive.NewObjectID().Hex(),
+		Type:          eventType,
+		Version:       schema.version,
+		OccurredAt:    time.Now().UTC(),
+		AggregateType: schema.aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       payload,
+	}, nil
+}
+
+// EventPublisher queues domain events in the outbox, keyed by aggregate ID so that
+// the events of one aggregate land on one partition and stay in order. Events join
+// the transaction in ctx, if any.
+type EventPublisher struct {
+	outbox domain.OutboxRepository
+	topic  string
+}
+
+func NewEventPublisher(outbox domain.OutboxRepository, topic string) *EventPublisher {
+	return &EventPublisher{outbox: outbox, topic: topic}
+}
+
+func (p *EventPublisher) Publish(ctx context.Context, eventType, aggregateID string, payload interface{}) error {
+	envelope, err := NewEventEnvelope(eventType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+	msg, err := NewOutboxMessage(p.topic, SchemaInventoryEvent, aggregateID, envelope)
+	if err != nil {
+		return err
+	}
+	return p.outbox.Add(ctx, msg)
+}
@@ -2,54 +2,206 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"errors"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/logging"
+	"inventory-service/infrastructure/metrics"
+	"inventory-service/infrastructure/tracing"
+	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/IBM/sarama"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// KafkaProducer publishes messages to Kafka.
 type KafkaProducer struct {
+	client   sarama.Client
 	producer sarama.SyncProducer
-	topic    string
 }
 
-func NewKafkaProducer(broker, topic string) *KafkaProducer {
+func NewKafkaProducer(brokers []string) (*KafkaProducer, error) {
 	config := sarama.NewConfig()
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 5
 
-	producer, err := sarama.NewSyncProducer([]string{broker}, config)
+	client, err := sarama.NewClient(brokers, config)
 	if err != nil {
-		log.Fatalf("Failed to create Kafka producer: %v", err)
+		return nil, err
 	}
-	return &KafkaProducer{
-		producer: producer,
-		topic:    topic,
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
 	}
+	return &KafkaProducer{client: client, producer: producer}, nil
 }
 
 type EmailMessage struct {
-	Type    string `json:"type"`
-	To      string `json:"to"`
-	Token   string `json:"token"`
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
+	ID       string `json:"id"` // Identifies the email across redeliveries and replays
+	Type     string `json:"type"`
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`                // Plain-text part
+	HTMLBody string `json:"html_body,omitempty"` // HTML part; the email is text-only when empty
+}
+
+// Publish sends an already encoded message. Messages with the same non-empty key land
+// on the same partition and so keep their relative order. The message carries the
+// trace context of its publishing span in its headers.
+func (p *KafkaProducer) Publish(ctx context.Context, msg *domain.Message) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, msg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemKafka, semconv.MessagingDestinationName(msg.Topic)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	headers := make(map[string]string, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	tracing.Inject(ctx, headers)
+
+	record := &sarama.ProducerMessage{
+		Topic: msg.Topic,
+		Value: sarama.ByteEncoder(msg.Value),
+	}
+	if msg.Key != nil {
+		record.Key = sarama.ByteEncoder(msg.Key)
+	}
+	for k, v := range headers {
+		record.Headers = append(record.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	_, _, err = p.producer.SendMessage(record)
+	metrics.MessageProduced(msg.Topic, err)
+	return err
+}
+
+// Ping fetches the cluster metadata, which needs a broker to answer. The client
+// does not take a context; the caller bounds how long it waits.
+func (p *KafkaProducer) Ping(ctx context.Context) error {
+	if err := p.client.RefreshMetadata(); err != nil {
+		return err
+	}
+	if len(p.client.Brokers()) == 0 {
+		return errors.New("no Kafka broker available")
+	}
+	return nil
+}
+
+// Close closes the producer and then the client it was created from, which a
+// producer created from a client leaves open.
+func (p *KafkaProducer) Close() error {
+	if err := p.producer.Close(); err != nil {
+		p.client.Close()
+		return err
+	}
+	return p.client.Close()
 }
 
-func (p *KafkaProducer) SendEmailMessage(ctx context.Context, msg EmailMessage) error {
-	data, err := json.Marshal(msg)
+// KafkaSubscriber consumes Kafka topics through consumer groups.
+type KafkaSubscriber struct {
+	brokers []string
+}
+
+func NewKafkaSubscriber(brokers []string) *KafkaSubscriber {
+	return &KafkaSubscriber{brokers: brokers}
+}
+
+// Subscribe consumes topic until ctx is done. The message being handled then sees
+// its context cancelled and, unless it completes, is redelivered later; the offsets of
+// the messages handled are committed before Subscribe returns.
+func (s *KafkaSubscriber) Subscribe(ctx context.Context, group, topic string, handler domain.MessageHandler) error {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	consumerGroup, err := sarama.NewConsumerGroup(s.brokers, group, config)
 	if err != nil {
 		return err
 	}
+	defer consumerGroup.Close()
 
-	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
-		Topic: p.topic,
-		Value: sarama.ByteEncoder(data),
-	})
+	for ctx.Err() == nil {
+		if err := consumerGroup.Consume(ctx, []string{topic}, &groupHandler{group: group, handler: handler}); err != nil {
+			slog.ErrorContext(ctx, "Failed to consume topic", "topic", topic, "group", group, "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return nil
+}
+
+// groupHandler adapts a MessageHandler to a sarama consumer group.
+type groupHandler struct {
+	group   string
+	handler domain.MessageHandler
+}
+
+func (h *groupHandler) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+// Cleanup commits the offsets marked so far, so that a stopping consumer does not
+// leave them to the next auto-commit tick, which never comes.
+func (h *groupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	session.Commit()
+	return nil
+}
+
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			// Unhandled messages stay unmarked and are redelivered after the next rebalance
+			err := h.handle(session.Context(), fromConsumerMessage(msg))
+			metrics.MessageConsumed(msg.Topic, h.group, msg.Partition, msg.Offset, claim.HighWaterMarkOffset(), err)
+			if err != nil {
+				return err
+			}
+			session.MarkMessage(msg, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// handle runs the handler in a span continuing the trace of the message's producer,
+// with the message identified in the log lines of the handler.
+func (h *groupHandler) handle(ctx context.Context, msg *domain.Message) error {
+	ctx = logging.With(ctx, "topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, msg.Headers), msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingKafkaConsumerGroup(h.group),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(msg.Partition))),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		),
+	)
+	err := h.handler(ctx, msg)
+	tracing.End(span, err)
 	return err
 }
 
-func (p *KafkaProducer) Close() {
-	p.producer.Close()
+func fromConsumerMessage(msg *sarama.ConsumerMessage) *domain.Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	return &domain.Message{
+		Topic:     msg.Topic,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+	}
 }
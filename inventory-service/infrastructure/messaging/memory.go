@@ -0,0 +1,204 @@
+package messaging
+
+import (
+	"context"
+	"hash/fnv"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/logging"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// memoryRetryDelay is how long a member waits before redelivering a message its
+// handler failed on.
+const memoryRetryDelay = 100 * time.Millisecond
+
+// MemoryBroker is an in-process message broker for tests and for running the
+// service without Kafka. Like Kafka, each topic is split into partitions chosen by
+// message key, each consumer group sees every message once, and a group's members
+// share the partitions so that messages with the same key are handled in order.
+// Messages are kept for the life of the broker.
+type MemoryBroker struct {
+	partitions int
+
+	mu      sync.Mutex
+	topics  map[string][][]*domain.Message
+	groups  map[string]*memoryGroup // By group and topic
+	next    int                     // Round-robin partition for messages without a key
+	changed chan struct{}           // Closed and replaced whenever there is news for members
+}
+
+type memoryGroup struct {
+	members []*memoryMember
+	offsets []int64 // Next offset to deliver, by partition
+	busy    []bool  // Whether a message of the partition is being handled
+}
+
+// memoryMember identifies a subscription; it is not zero-sized, as pointers to
+// distinct zero-sized values may compare equal.
+type memoryMember struct{ _ byte }
+
+// NewMemoryBroker returns a broker whose topics have the given number of partitions.
+func NewMemoryBroker(partitions int) *MemoryBroker {
+	if partitions < 1 {
+		partitions = 1
+	}
+	return &MemoryBroker{
+		partitions: partitions,
+		topics:     make(map[string][][]*domain.Message),
+		groups:     make(map[string]*memoryGroup),
+		changed:    make(chan struct{}),
+	}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, msg *domain.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partition := b.partitionFor(msg.Key)
+	topicLog := b.topic(msg.Topic)
+	stored := *msg
+	stored.Partition = int32(partition)
+	stored.Offset = int64(len(topicLog[partition]))
+	stored.Headers = make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		stored.Headers[k] = v
+	}
+	topicLog[partition] = append(topicLog[partition], &stored)
+	b.notify()
+	return nil
+}
+
+func (b *MemoryBroker) Close() error { return nil }
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, group, topic string, handler domain.MessageHandler) error {
+	member := &memoryMember{}
+	b.join(group, topic, member)
+	defer b.leave(group, topic, member)
+
+	for ctx.Err() == nil {
+		msg, changed := b.claim(group, topic, member)
+		if msg == nil {
+			select {
+			case <-ctx.Done():
+			case <-changed:
+			}
+			continue
+		}
+
+		msgCtx := logging.With(ctx, "topic", topic, "partition", msg.Partition, "offset", msg.Offset)
+		err := handler(msgCtx, msg)
+		b.release(group, topic, msg, err == nil)
+		if err != nil {
+			slog.ErrorContext(msgCtx, "Failed to handle message", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(memoryRetryDelay):
+			}
+		}
+	}
+	return nil
+}
+
+// Messages returns the messages published to topic so far, in publishing order
+// within each partition.
+func (b *MemoryBroker) Messages(topic string) []*domain.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var messages []*domain.Message
+	for _, partition := range b.topics[topic] {
+		messages = append(messages, partition...)
+	}
+	return messages
+}
+
+// partitionFor picks a partition the way Kafka's default partitioner does in spirit:
+// by key hash, or round robin for messages without a key.
+func (b *MemoryBroker) partitionFor(key []byte) int {
+	if key == nil {
+		b.next = (b.next + 1) % b.partitions
+		return b.next
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(b.partitions))
+}
+
+func (b *MemoryBroker) topic(name string) [][]*domain.Message {
+	if b.topics[name] == nil {
+		b.topics[name] = make([][]*domain.Message, b.partitions)
+	}
+	return b.topics[name]
+}
+
+func (b *MemoryBroker) group(group, topic string) *memoryGroup {
+	key := group + "\x00" + topic
+	g := b.groups[key]
+	if g == nil {
+		g = &memoryGroup{offsets: make([]int64, b.partitions), busy: make([]bool, b.partitions)}
+		b.groups[key] = g
+	}
+	return g
+}
+
+func (b *MemoryBroker) join(group, topic string, member *memoryMember) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g := b.group(group, topic)
+	g.members = append(g.members, member)
+	b.notify()
+}
+
+func (b *MemoryBroker) leave(group, topic string, member *memoryMember) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g := b.group(group, topic)
+	for i, m := range g.members {
+		if m == member {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	b.notify()
+}
+
+// claim returns the next message of a partition assigned to member, marking the
+// partition busy, or nil and a channel closed on the next change. Partitions are
+// assigned round robin over the members in joining order.
+func (b *MemoryBroker) claim(group, topic string, member *memoryMember) (*domain.Message, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g := b.group(group, topic)
+	index := -1
+	for i, m := range g.members {
+		if m == member {
+			index = i
+		}
+	}
+	topicLog := b.topic(topic)
+	for partition := index; index >= 0 && partition < b.partitions; partition += len(g.members) {
+		if !g.busy[partition] && g.offsets[partition] < int64(len(topicLog[partition])) {
+			g.busy[partition] = true
+			return topicLog[partition][g.offsets[partition]], nil
+		}
+	}
+	return nil, b.changed
+}
+
+// release ends the handling of msg, moving the group past it if it was handled.
+func (b *MemoryBroker) release(group, topic string, msg *domain.Message, handled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g := b.group(group, topic)
+	g.busy[msg.Partition] = false
+	if handled {
+		g.offsets[msg.Partition] = msg.Offset + 1
+	}
+	b.notify()
+}
+
+func (b *MemoryBroker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
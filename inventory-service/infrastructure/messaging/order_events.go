@@ -0,0 +1,89 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"inventory-service/domain"
+	"log/slog"
+	"time"
+)
+
+// Order event types consumed from order-service.
+const (
+	EventOrderPlaced    = "order.placed"
+	EventOrderPaid      = "order.paid"
+	EventOrderCancelled = "order.cancelled"
+	EventOrderRefunded  = "order.refunded"
+)
+
+// OrderEvent is an order lifecycle event. It uses the same envelope as the events
+// inventory-service publishes, with the order as the aggregate.
+type OrderEvent struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Version    int               `json:"version"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	OrderID    string            `json:"aggregate_id"`
+	Payload    OrderEventPayload `json:"payload"`
+}
+
+type OrderEventPayload struct {
+	// Items is only required on order.placed; later events refer to the stock
+	// reserved then
+	Items []OrderEventItem `json:"items"`
+}
+
+type OrderEventItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// OrderEventHandler applies an order event. It must be idempotent, as an event is
+// redelivered when the consumer stops before marking it consumed.
+type OrderEventHandler func(ctx context.Context, event OrderEvent) error
+
+// OrderEventConsumer hands the events on the order events topic to a handler in
+// order. A failed event is retried until it succeeds rather than skipped, as the
+// events of an order only make sense in sequence; only events that do not conform to
+// their schema are moved to the dead-letter topic.
+type OrderEventConsumer struct {
+	handle    OrderEventHandler
+	publisher domain.Publisher
+	dlqTopic  string
+	retry     RetryPolicy
+}
+
+func NewOrderEventConsumer(handle OrderEventHandler, publisher domain.Publisher, dlqTopic string, retry RetryPolicy) *OrderEventConsumer {
+	return &OrderEventConsumer{handle: handle, publisher: publisher, dlqTopic: dlqTopic, retry: retry}
+}
+
+// Run consumes topic as part of group until ctx is cancelled.
+func (c *OrderEventConsumer) Run(ctx context.Context, subscriber domain.Subscriber, group, topic string) error {
+	return subscriber.Subscribe(ctx, group, topic, c.process)
+}
+
+func (c *OrderEventConsumer) process(ctx context.Context, msg *domain.Message) error {
+	var event OrderEvent
+	if err := DecodeMessage(msg, SchemaOrderEvent, &event); err != nil {
+		// Retrying cannot fix a malformed event, and blocking on it would stall every
+		// order on the partition
+		slog.WarnContext(ctx, "Dead-lettering malformed order event", "error", err)
+		return deadLetter(ctx, c.publisher, c.dlqTopic, msg, 0, err)
+	}
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.handle(ctx, event)
+		if err == nil {
+			return nil
+		}
+		slog.WarnContext(ctx, "Failed to apply order event", "event_type", event.Type, "event_id", event.ID, "order_id", event.OrderID, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.retry.delay(attempt)):
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package messaging
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/tracing"
+	"log/slog"
+	"time"
+)
+
+// outboxLease is how long a relay may take to publish a claimed message before
+// another relay may pick it up.
+const outboxLease = 30 * time.Second
+
+// NewOutboxMessage encodes value as JSON for publishing to topic through the outbox,
+// after checking it against the current version of schema.
+func NewOutboxMessage(topic, schema, key string, value interface{}) (*models.OutboxMessage, error) {
+	data, headers, err := EncodeMessage(schema, value)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxMessage{
+		Topic:     topic,
+		Key:       key,
+		Payload:   string(data),
+		Headers:   headers,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// OutboxRelay publishes outbox messages in the order they were written.
+// Delivery is at least once: a message published just before a crash, or before its
+// lease ran out, is published again, so consumers must tolerate duplicates.
+type OutboxRelay struct {
+	repo      domain.OutboxRepository
+	publisher domain.Publisher
+	interval  time.Duration
+}
+
+func NewOutboxRelay(repo domain.OutboxRepository, publisher domain.Publisher, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{repo: repo, publisher: publisher, interval: interval}
+}
+
+// Run publishes pending messages every interval until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publishes messages until none are left or publishing fails. On failure it
+// waits for the next tick rather than skipping ahead, which keeps messages in order.
+func (r *OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		msg, err := r.repo.ClaimNext(ctx, outboxLease)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim outbox message", "error", err)
+			return
+		}
+		if msg == nil {
+			return
+		}
+
+		var key []byte
+		if msg.Key != "" {
+			key = []byte(msg.Key)
+		}
+		// Publishing continues the trace of the request that wrote the message
+		if err := r.publisher.Publish(tracing.Extract(ctx, msg.Headers), &domain.Message{
+			Topic:   msg.Topic,
+			Key:     key,
+			Value:   []byte(msg.Payload),
+			Headers: msg.Headers,
+		}); err != nil {
+			slog.WarnContext(ctx, "Failed to publish outbox message", "outbox_id", msg.ID.Hex(), "topic", msg.Topic, "error", err)
+			if err := r.repo.MarkFailed(ctx, msg, err); err != nil {
+				slog.ErrorContext(ctx, "Failed to record outbox failure", "outbox_id", msg.ID.Hex(), "error", err)
+			}
+			return
+		}
+		if err := r.repo.MarkSent(ctx, msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to mark outbox message sent", "outbox_id", msg.ID.Hex(), "error", err)
+			return
+		}
+	}
+}
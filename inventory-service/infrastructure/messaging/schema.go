@@ -0,0 +1,235 @@
+package messaging
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inventory-service/domain"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Message schemas, one per kind of message the service produces or consumes. Each
+// version of a schema lives in schemas/<name>.v<version>.json; a published version
+// is never changed incompatibly, a new version is added instead.
+const (
+	SchemaEmail          = "email"
+	SchemaAuditEntry     = "audit_entry"
+	SchemaInventoryEvent = "inventory_event"
+	SchemaOrderEvent     = "order_event"
+)
+
+// currentSchemaVersions is the version each schema is produced with. Consumers accept
+// every version that has a schema file.
+var currentSchemaVersions = map[string]int{
+	SchemaEmail:          2,
+	SchemaAuditEntry:     1,
+	SchemaInventoryEvent: 1,
+	SchemaOrderEvent:     1,
+}
+
+// Headers naming the schema a message conforms to. Messages produced before schemas
+// were introduced carry neither and are taken to be version 1.
+const (
+	HeaderSchema        = "x-schema"
+	HeaderSchemaVersion = "x-schema-version"
+)
+
+// ErrUnknownSchema is returned for a schema name or version without a schema file.
+var ErrUnknownSchema = errors.New("unknown message schema")
+
+// SchemaError reports a message that does not conform to its schema.
+type SchemaError struct {
+	Schema  string
+	Version int
+	Err     error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("message does not conform to schema %s v%d: %v", e.Schema, e.Version, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var (
+	compileOnce     sync.Once
+	compiledSchemas map[string]map[int]*jsonschema.Schema
+	compileErr      error
+)
+
+// CompileSchemas compiles the embedded schema files. It runs once, on first use;
+// calling it up front reports a broken schema file at startup.
+func CompileSchemas() error {
+	compileOnce.Do(func() {
+		compiledSchemas, compileErr = compileSchemas(schemaFiles)
+	})
+	return compileErr
+}
+
+func compileSchemas(files fs.FS) (map[string]map[int]*jsonschema.Schema, error) {
+	paths, err := fs.Glob(files, "schemas/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	schemas := make(map[string]map[int]*jsonschema.Schema)
+	for _, p := range paths {
+		name, version, err := parseSchemaFileName(path.Base(p))
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(files, p)
+		if err != nil {
+			return nil, err
+		}
+		if err := compiler.AddResource(p, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", p, err)
+		}
+		schema, err := compiler.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", p, err)
+		}
+		if schemas[name] == nil {
+			schemas[name] = make(map[int]*jsonschema.Schema)
+		}
+		schemas[name][version] = schema
+	}
+
+	for name, version := range currentSchemaVersions {
+		if schemas[name][version] == nil {
+			return nil, fmt.Errorf("schema %s v%d is produced but has no schema file", name, version)
+		}
+	}
+	return schemas, nil
+}
+
+// parseSchemaFileName splits e.g. "email.v1.json" into "email" and 1.
+func parseSchemaFileName(file string) (string, int, error) {
+	base := strings.TrimSuffix(file, ".json")
+	i := strings.LastIndex(base, ".v")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("schema file %s is not named <name>.v<version>.json", file)
+	}
+	version, err := strconv.Atoi(base[i+2:])
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("schema file %s is not named <name>.v<version>.json", file)
+	}
+	return base[:i], version, nil
+}
+
+// SchemaVersion returns the version a schema is produced with.
+func SchemaVersion(schema string) int {
+	return currentSchemaVersions[schema]
+}
+
+// SchemaVersions returns the versions of a schema that have a schema file, oldest
+// first.
+func SchemaVersions(schema string) ([]int, error) {
+	if err := CompileSchemas(); err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(compiledSchemas[schema]))
+	for version := range compiledSchemas[schema] {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// SchemaNames returns the names of the schemas with a schema file.
+func SchemaNames() ([]string, error) {
+	if err := CompileSchemas(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(compiledSchemas))
+	for name := range compiledSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ValidateMessage checks an encoded message against a version of a schema.
+func ValidateMessage(schema string, version int, data []byte) error {
+	if err := CompileSchemas(); err != nil {
+		return err
+	}
+	compiled := compiledSchemas[schema][version]
+	if compiled == nil {
+		return fmt.Errorf("%w: %s v%d", ErrUnknownSchema, schema, version)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return &SchemaError{Schema: schema, Version: version, Err: err}
+	}
+	if err := compiled.Validate(doc); err != nil {
+		return &SchemaError{Schema: schema, Version: version, Err: err}
+	}
+	return nil
+}
+
+// EncodeMessage encodes value as JSON, checks it against the current version of
+// schema and returns it with the headers naming that version.
+func EncodeMessage(schema string, value interface{}) ([]byte, map[string]string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	version := SchemaVersion(schema)
+	if err := ValidateMessage(schema, version, data); err != nil {
+		return nil, nil, err
+	}
+	return data, map[string]string{
+		HeaderSchema:        schema,
+		HeaderSchemaVersion: strconv.Itoa(version),
+	}, nil
+}
+
+// DecodeMessage checks a consumed message against the schema version named in its
+// headers and decodes it into v. A message declaring a different schema is rejected.
+func DecodeMessage(msg *domain.Message, schema string, v interface{}) error {
+	version := 1
+	if name, ok := msg.Headers[HeaderSchema]; ok && name != schema {
+		return &SchemaError{Schema: schema, Version: version, Err: fmt.Errorf("message declares schema %q", name)}
+	}
+	if value, ok := msg.Headers[HeaderSchemaVersion]; ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return &SchemaError{Schema: schema, Version: version, Err: fmt.Errorf("invalid %s header %q", HeaderSchemaVersion, value)}
+		}
+		version = parsed
+	}
+	if err := ValidateMessage(schema, version, msg.Value); err != nil {
+		return err
+	}
+	return json.Unmarshal(msg.Value, v)
+}
+
+// schemaHeaders returns the schema headers of a consumed message, so that they
+// travel with it to a dead-letter topic and back.
+func schemaHeaders(msg *domain.Message) map[string]string {
+	headers := make(map[string]string)
+	for _, key := range []string{HeaderSchema, HeaderSchemaVersion} {
+		if value, ok := msg.Headers[key]; ok {
+			headers[key] = value
+		}
+	}
+	return headers
+}
@@ -0,0 +1,186 @@
+package messaging
+
+import (
+	"encoding/json"
+	"inventory-service/domain/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// An incompatible change to a Kafka message schema needs a new schema version with
+// its own samples; these tests fail otherwise.
+
+const samplesDir = "schemas/samples"
+
+// TestSchemaVersions checks that every schema file compiles, and that each schema has
+// versions 1 to the version produced without gaps.
+func TestSchemaVersions(t *testing.T) {
+	if err := CompileSchemas(); err != nil {
+		t.Fatal(err)
+	}
+	names, err := SchemaNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		versions, err := SchemaVersions(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, version := range versions {
+			if version != i+1 {
+				t.Errorf("%s: version %d is missing", name, i+1)
+				break
+			}
+		}
+		if current := SchemaVersion(name); current != len(versions) {
+			t.Errorf("%s: produced as v%d but the latest schema file is v%d", name, current, len(versions))
+		}
+	}
+}
+
+// TestSchemaSamples checks that every version has sample messages and that they all
+// still conform to it, so that a published version cannot start rejecting messages
+// already on the topics.
+func TestSchemaSamples(t *testing.T) {
+	names, err := SchemaNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		versions, err := SchemaVersions(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, version := range versions {
+			dir := filepath.Join(samplesDir, name+".v"+strconv.Itoa(version))
+			samples, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(samples) == 0 {
+				t.Errorf("%s v%d: no sample messages in %s", name, version, dir)
+			}
+			for _, sample := range samples {
+				data, err := os.ReadFile(sample)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := ValidateMessage(name, version, data); err != nil {
+					t.Errorf("%s: %v", sample, err)
+				}
+			}
+		}
+	}
+}
+
+// TestCurrentMessages checks that the messages the service builds today conform to
+// the version produced.
+func TestCurrentMessages(t *testing.T) {
+	messages, err := currentMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range messages {
+		data, err := json.Marshal(msg.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateMessage(msg.schema, SchemaVersion(msg.schema), data); err != nil {
+			t.Errorf("%s built by the service: %v", msg.description, err)
+		}
+	}
+}
+
+type message struct {
+	description string
+	schema      string
+	value       interface{}
+}
+
+// currentMessages returns a message of each kind as the service builds it today,
+// including the optional fields, to catch a Go type drifting from its schema.
+func currentMessages() ([]message, error) {
+	productID := primitive.NewObjectID()
+	categoryID := primitive.NewObjectID()
+	product := &models.Product{
+		ID:          productID,
+		Name:        "Desk lamp",
+		Description: "LED desk lamp",
+		Price:       24.5,
+		Stock:       40,
+		ImageURL:    "https://example.com/lamp.png",
+		Category:    "Lighting",
+		Reserved:    2,
+	}
+	category := &models.Category{ID: categoryID, Name: "Lighting", Description: "Lamps and bulbs"}
+
+	messages := []message{
+		{"email", SchemaEmail, EmailMessage{
+			ID:       primitive.NewObjectID().Hex(),
+			Type:     "verification",
+			To:       "jane@example.com",
+			Subject:  "Verify your email address",
+			Body:     "Verify your email address",
+			HTMLBody: "<p>Verify your email address</p>",
+		}},
+		{"audit entry", SchemaAuditEntry, &models.AuditEntry{
+			ID:         primitive.NewObjectID(),
+			OccurredAt: time.Now().UTC(),
+			ActorID:    primitive.NewObjectID().Hex(),
+			ActorRole:  "admin",
+			Action:     "product.update",
+			TargetType: "product",
+			TargetID:   productID.Hex(),
+			Before:     product,
+			After:      product,
+			IP:         "10.0.0.12",
+			RequestID:  "c0ffee",
+			Method:     "PUT",
+			Path:       "/products/" + productID.Hex(),
+			Status:     200,
+		}},
+		{"order.placed event", SchemaOrderEvent, OrderEvent{
+			ID:         "b7e4c1f0-2d7a-4c5e-9a51-0f3f2a1c9e01",
+			Type:       EventOrderPlaced,
+			Version:    1,
+			OccurredAt: time.Now().UTC(),
+			OrderID:    "1042",
+			Payload: OrderEventPayload{
+				Items: []OrderEventItem{{ProductID: productID.Hex(), Quantity: 2}},
+			},
+		}},
+	}
+
+	events := []struct {
+		eventType   string
+		aggregateID string
+		payload     interface{}
+	}{
+		{EventProductCreated, productID.Hex(), product},
+		{EventProductUpdated, productID.Hex(), product},
+		{EventProductDeleted, productID.Hex(), DeletedPayload{ID: productID.Hex()}},
+		{EventCategoryCreated, categoryID.Hex(), category},
+		{EventCategoryUpdated, categoryID.Hex(), category},
+		{EventCategoryDeleted, categoryID.Hex(), DeletedPayload{ID: categoryID.Hex()}},
+		{EventStockChanged, productID.Hex(), StockChangedPayload{
+			ProductID: productID.Hex(),
+			Delta:     -2,
+			Stock:     38,
+			Reason:    "order_placed",
+		}},
+	}
+	for _, event := range events {
+		envelope, err := NewEventEnvelope(event.eventType, event.aggregateID, event.payload)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message{event.eventType + " event", SchemaInventoryEvent, envelope})
+	}
+	return messages, nil
+}
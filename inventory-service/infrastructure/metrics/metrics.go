@@ -0,0 +1,179 @@
+// Package metrics defines the Prometheus metrics of the service, registered with the
+// default registry and served on /metrics by Handler.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const namespace = "inventory"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "MongoDB command latency by command and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "outcome"})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	lockFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lock_acquisition_failures_total",
+		Help:      "Failed distributed lock acquisitions by lock and reason (held or error).",
+	}, []string{"lock", "reason"})
+
+	kafkaProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_produced_total",
+		Help:      "Messages published to Kafka by topic and outcome.",
+	}, []string{"topic", "outcome"})
+
+	kafkaConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_consumed_total",
+		Help:      "Messages consumed from Kafka by topic, consumer group and outcome.",
+	}, []string{"topic", "group", "outcome"})
+
+	kafkaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages behind the end of the partition, as of the last message consumed.",
+	}, []string{"topic", "group", "partition"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest records a handled request. route is the route template, e.g.
+// /inventory/api/products/{id}, so that IDs do not each make a new series.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// MongoMonitor times every command the MongoDB driver sends.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "success").Observe(e.Duration.Seconds())
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "failure").Observe(e.Duration.Seconds())
+		},
+	}
+}
+
+// ObserveCache records a lookup in a cache, e.g. "product" or "category".
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// LockFailed records a lock that could not be acquired, either because it was held
+// or because of an error.
+func LockFailed(lock string, err error) {
+	reason := "held"
+	if err != nil {
+		reason = "error"
+	}
+	lockFailures.WithLabelValues(lock, reason).Inc()
+}
+
+// MessageProduced records a message published to topic.
+func MessageProduced(topic string, err error) {
+	kafkaProduced.WithLabelValues(topic, outcome(err)).Inc()
+}
+
+// MessageConsumed records a message handled by group, and how far the group is
+// behind the high water mark of the partition.
+func MessageConsumed(topic, group string, partition int32, offset, highWaterMark int64, err error) {
+	kafkaConsumed.WithLabelValues(topic, group, outcome(err)).Inc()
+	// The high water mark is the offset of the next message to be written
+	lag := highWaterMark - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	kafkaLag.WithLabelValues(topic, group, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// StockSummary returns the total units in stock and the number of products out of
+// stock.
+type StockSummary func(ctx context.Context) (units, outOfStock int, err error)
+
+// stockCollector computes the stock gauges when scraped, so that they are never
+// stale and cost nothing between scrapes.
+type stockCollector struct {
+	summary    StockSummary
+	timeout    time.Duration
+	units      *prometheus.Desc
+	outOfStock *prometheus.Desc
+}
+
+// RegisterStockCollector exposes the stock gauges, computed by summary within
+// timeout on each scrape. A failing summary leaves the gauges out of the scrape.
+func RegisterStockCollector(summary StockSummary, timeout time.Duration) {
+	prometheus.MustRegister(&stockCollector{
+		summary:    summary,
+		timeout:    timeout,
+		units:      prometheus.NewDesc(namespace+"_stock_units", "Units in stock over all products.", nil, nil),
+		outOfStock: prometheus.NewDesc(namespace+"_products_out_of_stock", "Products with no unit in stock.", nil, nil),
+	})
+}
+
+func (c *stockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.units
+	ch <- c.outOfStock
+}
+
+func (c *stockCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	units, outOfStock, err := c.summary(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to compute the stock metrics", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.units, prometheus.GaugeValue, float64(units))
+	ch <- prometheus.MustNewConstMetric(c.outOfStock, prometheus.GaugeValue, float64(outOfStock))
+}
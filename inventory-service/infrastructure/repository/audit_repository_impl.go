@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(client *db.MongoClient, dbName, collectionName string) domain.AuditRepository {
+	// Decode snapshots as maps so they render as JSON objects rather than key/value lists
+	collOpts := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	return &AuditRepositoryImpl{collection: client.Client.Database(dbName).Collection(collectionName, collOpts)}
+}
+
+func (r *AuditRepositoryImpl) Append(ctx context.Context, entry *models.AuditEntry) error {
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *AuditRepositoryImpl) Find(ctx context.Context, filter domain.AuditFilter, page, limit int) ([]*models.AuditEntry, int64, error) {
+	query := bson.M{}
+	if filter.ActorID != "" {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetType != "" {
+		query["target_type"] = filter.TargetType
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		occurred := bson.M{}
+		if !filter.From.IsZero() {
+			occurred["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			occurred["$lte"] = filter.To
+		}
+		query["occurred_at"] = occurred
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		if page < 1 {
+			page = 1
+		}
+		findOpts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := []*models.AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *AuditRepositoryImpl) FindByUser(ctx context.Context, userID string) ([]*models.AuditEntry, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"actor_id": userID},
+		bson.M{"target_type": "user", "target_id": userID},
+	}}
+	findOpts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*models.AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AnonymizeUser keeps the entries, so that the log still shows what happened and
+// by which (now meaningless) user ID, but drops what identifies the person.
+func (r *AuditRepositoryImpl) AnonymizeUser(ctx context.Context, userID string) error {
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"actor_id": userID}, bson.M{"$set": bson.M{"ip": ""}}); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"target_type": "user", "target_id": userID},
+		bson.M{"$unset": bson.M{"before": "", "after": ""}})
+	return err
+}
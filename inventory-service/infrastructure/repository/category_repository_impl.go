@@ -9,6 +9,7 @@ import (
 	"inventory-service/infrastructure/cache"
 	"inventory-service/infrastructure/db"
 	"inventory-service/infrastructure/lock"
+	"inventory-service/infrastructure/metrics"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -32,80 +33,89 @@ func NewCategoryRepository(client *db.MongoClient, dbName, collection string, re
 	}
 }
 
+// getLock returns the lock guarding a write; see ProductRepositoryImpl.getLock.
 func (r *CategoryRepositoryImpl) getLock(ctx context.Context, operation, id string) *lock.DistributedLock {
 	return lock.NewDistributedLock(r.redis.Client, fmt.Sprintf("lock:category:%s:%s", operation, id), "lock-value", 30*time.Second)
 }
 
-func (r *CategoryRepositoryImpl) Create(category *models.Category) error {
-	ctx := context.Background()
+func (r *CategoryRepositoryImpl) Create(ctx context.Context, category *models.Category) error {
 	lock := r.getLock(ctx, "create", category.ID.Hex())
 	acquired, err := lock.Acquire(ctx)
 	if err != nil || !acquired {
-		return fmt.Errorf("failed to acquire lock: %v", err)
+		return lockError("category", err)
 	}
-	defer lock.Release(ctx)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	_, err = coll.InsertOne(ctx, category)
+	result, err := coll.InsertOne(ctx, category)
 	if err != nil {
-		return err
+		return writeError(err, "category already exists")
 	}
+	category.ID = result.InsertedID.(primitive.ObjectID)
 	r.redis.DeleteCache(ctx, "categories:all")
 	return nil
 }
 
-func (r *CategoryRepositoryImpl) Update(category *models.Category) error {
-	ctx := context.Background()
+func (r *CategoryRepositoryImpl) Update(ctx context.Context, category *models.Category) error {
 	lock := r.getLock(ctx, "update", category.ID.Hex())
 	acquired, err := lock.Acquire(ctx)
 	if err != nil || !acquired {
-		return fmt.Errorf("failed to acquire lock: %v", err)
+		return lockError("category", err)
 	}
-	defer lock.Release(ctx)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	filter := bson.M{"_id": category.ID}
 	update := bson.M{"$set": category}
-	_, err = coll.UpdateOne(ctx, filter, update)
+	result, err := coll.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return writeError(err, "category already exists")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrCategoryNotFound
 	}
 	r.redis.DeleteCache(ctx, "categories:all")
 	r.redis.DeleteCache(ctx, fmt.Sprintf("category:%s", category.ID.Hex()))
 	return nil
 }
 
-func (r *CategoryRepositoryImpl) Delete(id string) error {
-	ctx := context.Background()
+func (r *CategoryRepositoryImpl) Delete(ctx context.Context, id string) error {
 	lock := r.getLock(ctx, "delete", id)
 	acquired, err := lock.Acquire(ctx)
 	if err != nil || !acquired {
-		return fmt.Errorf("failed to acquire lock: %v", err)
+		return lockError("category", err)
 	}
-	defer lock.Release(ctx)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	objID, _ := primitive.ObjectIDFromHex(id)
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrCategoryNotFound
+	}
 	filter := bson.M{"_id": objID}
-	_, err = coll.DeleteOne(ctx, filter)
+	result, err := coll.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
+	if result.DeletedCount == 0 {
+		return domain.ErrCategoryNotFound
+	}
 	r.redis.DeleteCache(ctx, "categories:all")
 	r.redis.DeleteCache(ctx, fmt.Sprintf("category:%s", id))
 	return nil
 }
 
-func (r *CategoryRepositoryImpl) FindByID(id string) (*models.Category, error) {
-	ctx := context.Background()
+func (r *CategoryRepositoryImpl) FindByID(ctx context.Context, id string) (*models.Category, error) {
 	cacheKey := fmt.Sprintf("category:%s", id)
 
 	if cached, err := r.redis.GetCache(ctx, cacheKey); err == nil {
 		var category models.Category
 		if json.Unmarshal([]byte(cached), &category) == nil {
+			metrics.ObserveCache("category", true)
 			return &category, nil
 		}
 	}
+	metrics.ObserveCache("category", false)
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	objID, _ := primitive.ObjectIDFromHex(id)
@@ -125,16 +135,17 @@ func (r *CategoryRepositoryImpl) FindByID(id string) (*models.Category, error) {
 	return &category, nil
 }
 
-func (r *CategoryRepositoryImpl) FindAll() ([]*models.Category, error) {
-	ctx := context.Background()
+func (r *CategoryRepositoryImpl) FindAll(ctx context.Context) ([]*models.Category, error) {
 	cacheKey := "categories:all"
 
 	if cached, err := r.redis.GetCache(ctx, cacheKey); err == nil {
 		var categories []*models.Category
 		if json.Unmarshal([]byte(cached), &categories) == nil {
+			metrics.ObserveCache("category", true)
 			return categories, nil
 		}
 	}
+	metrics.ObserveCache("category", false)
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	cursor, err := coll.Find(ctx, bson.M{})
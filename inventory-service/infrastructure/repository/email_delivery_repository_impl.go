@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmailDeliveryRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewEmailDeliveryRepository(client *db.MongoClient, dbName, collectionName string) domain.EmailDeliveryRepository {
+	return &EmailDeliveryRepositoryImpl{collection: client.Client.Database(dbName).Collection(collectionName)}
+}
+
+func (r *EmailDeliveryRepositoryImpl) FindByMessageID(ctx context.Context, messageID string) (*models.EmailDelivery, error) {
+	var delivery models.EmailDelivery
+	err := r.collection.FindOne(ctx, bson.M{"message_id": messageID}).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *EmailDeliveryRepositoryImpl) Save(ctx context.Context, delivery *models.EmailDelivery) error {
+	filter := bson.M{"message_id": delivery.MessageID}
+	// The _id is left out of the replacement so that it is kept, or generated on insert
+	doc := *delivery
+	doc.ID = primitive.NilObjectID
+	_, err := r.collection.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *EmailDeliveryRepositoryImpl) Find(ctx context.Context, filter domain.EmailDeliveryFilter, page, limit int) ([]*models.EmailDelivery, int64, error) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.To != "" {
+		query["to"] = filter.To
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		if page < 1 {
+			page = 1
+		}
+		findOpts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	deliveries := []*models.EmailDelivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *EmailDeliveryRepositoryImpl) DeleteByRecipient(ctx context.Context, to string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"to": to})
+	return err
+}
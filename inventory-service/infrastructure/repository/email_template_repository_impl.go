@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/db"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmailTemplateRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewEmailTemplateRepository(client *db.MongoClient, dbName, collectionName string) domain.EmailTemplateRepository {
+	return &EmailTemplateRepositoryImpl{collection: client.Client.Database(dbName).Collection(collectionName)}
+}
+
+func (r *EmailTemplateRepositoryImpl) Find(ctx context.Context, name, locale string) (*models.EmailTemplate, error) {
+	var tmpl models.EmailTemplate
+	err := r.collection.FindOne(ctx, bson.M{"name": name, "locale": locale}).Decode(&tmpl)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// Upsert stores the template, replacing any existing one for the same name and locale.
+func (r *EmailTemplateRepositoryImpl) Upsert(ctx context.Context, tmpl *models.EmailTemplate) error {
+	filter := bson.M{"name": tmpl.Name, "locale": tmpl.Locale}
+	update := bson.M{"$set": bson.M{
+		"source":     tmpl.Source,
+		"updated_by": tmpl.UpdatedBy,
+		"updated_at": tmpl.UpdatedAt,
+	}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *EmailTemplateRepositoryImpl) Delete(ctx context.Context, name, locale string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"name": name, "locale": locale})
+	return err
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"fmt"
+	"inventory-service/domain"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// lockError reports a failure to take the lock guarding a write to a resource.
+// A lock held by another write is a conflict the client may retry; failing to reach
+// Redis is an internal error.
+func lockError(resource string, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return domain.NewConflictError(resource + " is being modified by another request, please try again")
+}
+
+// writeError turns a duplicate key error into a conflict reported with message.
+func writeError(err error, message string) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.NewConflictError(message)
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/db"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewInvitationRepository(client *db.MongoClient, dbName, collectionName string) domain.InvitationRepository {
+	return &InvitationRepositoryImpl{collection: client.Client.Database(dbName).Collection(collectionName)}
+}
+
+func (r *InvitationRepositoryImpl) Create(ctx context.Context, invitation *models.Invitation) error {
+	result, err := r.collection.InsertOne(ctx, invitation)
+	if err != nil {
+		return err
+	}
+	invitation.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *InvitationRepositoryImpl) Update(ctx context.Context, invitation *models.Invitation) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": invitation.ID}, bson.M{"$set": invitation})
+	return err
+}
+
+func (r *InvitationRepositoryImpl) FindByID(ctx context.Context, id string) (*models.Invitation, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil
+	}
+	return r.findOne(ctx, bson.M{"_id": objID})
+}
+
+func (r *InvitationRepositoryImpl) FindByTokenHash(ctx context.Context, hash string) (*models.Invitation, error) {
+	return r.findOne(ctx, bson.M{"token_hash": hash})
+}
+
+func (r *InvitationRepositoryImpl) FindPendingByEmail(ctx context.Context, email string) (*models.Invitation, error) {
+	filter := statusFilter(models.InvitationPending, time.Now())
+	filter["email"] = email
+	return r.findOne(ctx, filter)
+}
+
+func (r *InvitationRepositoryImpl) MarkAccepted(ctx context.Context, id string, at time.Time) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, nil
+	}
+	filter := statusFilter(models.InvitationPending, at)
+	filter["_id"] = objID
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"accepted_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *InvitationRepositoryImpl) FindAll(ctx context.Context, status string, page, limit int) ([]*models.Invitation, int64, error) {
+	filter := statusFilter(status, time.Now())
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		if page < 1 {
+			page = 1
+		}
+		findOpts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	invitations := []*models.Invitation{}
+	if err = cursor.All(ctx, &invitations); err != nil {
+		return nil, 0, err
+	}
+	return invitations, total, nil
+}
+
+func (r *InvitationRepositoryImpl) FindByUser(ctx context.Context, userID, email string) ([]*models.Invitation, error) {
+	filter := bson.M{"$or": bson.A{bson.M{"email": email}, bson.M{"invited_by": userID}}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	invitations := []*models.Invitation{}
+	if err = cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *InvitationRepositoryImpl) EraseUser(ctx context.Context, userID, email string) error {
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"email": email}); err != nil {
+		return err
+	}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"invited_by": userID}, bson.M{"$set": bson.M{"invited_by": ""}})
+	return err
+}
+
+func (r *InvitationRepositoryImpl) findOne(ctx context.Context, filter bson.M) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.collection.FindOne(ctx, filter).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// statusFilter mirrors models.Invitation.Status as a query
+func statusFilter(status string, now time.Time) bson.M {
+	switch status {
+	case models.InvitationAccepted:
+		return bson.M{"accepted_at": bson.M{"$exists": true}}
+	case models.InvitationRevoked:
+		return bson.M{"accepted_at": bson.M{"$exists": false}, "revoked_at": bson.M{"$exists": true}}
+	case models.InvitationExpired:
+		return bson.M{"accepted_at": bson.M{"$exists": false}, "revoked_at": bson.M{"$exists": false}, "expires_at": bson.M{"$lte": now}}
+	case models.InvitationPending:
+		return bson.M{"accepted_at": bson.M{"$exists": false}, "revoked_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": now}}
+	default:
+		return bson.M{}
+	}
+}
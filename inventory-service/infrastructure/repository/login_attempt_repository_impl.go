@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/cache"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type LoginAttemptRepositoryImpl struct {
+	redis *cache.RedisClient
+}
+
+func NewLoginAttemptRepository(redis *cache.RedisClient) domain.LoginAttemptRepository {
+	return &LoginAttemptRepositoryImpl{redis: redis}
+}
+
+func failuresKey(key string) string {
+	return fmt.Sprintf("login:failures:%s", key)
+}
+
+func lockoutKey(key string) string {
+	return fmt.Sprintf("login:lockout:%s", key)
+}
+
+func (r *LoginAttemptRepositoryImpl) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, failuresKey(key))
+		pipe.Expire(ctx, failuresKey(key), window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *LoginAttemptRepositoryImpl) Lock(ctx context.Context, key string, duration time.Duration) error {
+	return r.redis.Set(ctx, lockoutKey(key), "1", duration).Err()
+}
+
+func (r *LoginAttemptRepositoryImpl) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.redis.PTTL(ctx, lockoutKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 { // -2 when the key is missing, -1 when it has no expiry
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *LoginAttemptRepositoryImpl) Reset(ctx context.Context, key string) error {
+	return r.redis.Del(ctx, failuresKey(key), lockoutKey(key)).Err()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"inventory-service/infrastructure/db"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migration is a one-off change to existing data. It must be idempotent, as replicas
+// starting together may run it at the same time.
+type migration struct {
+	name string
+	run  func(ctx context.Context, database *mongo.Database) (modified int64, err error)
+}
+
+// migrations are applied in order, each once; append new ones at the end.
+var migrations = []migration{
+	{"backfill-user-created-at", backfillCreatedAt},
+	{"drop-sent-outbox-payloads", dropSentOutboxPayloads},
+}
+
+// Migrate applies the migrations not applied yet, recording each in the migrations
+// collection. The service must not start on an error, as the data is not in the
+// shape the code expects.
+func Migrate(ctx context.Context, client *db.MongoClient, dbName string) error {
+	database := client.Client.Database(dbName)
+	applied := database.Collection("migrations")
+	for _, m := range migrations {
+		err := applied.FindOne(ctx, bson.M{"_id": m.name}).Err()
+		if err == nil {
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+
+		modified, err := m.run(ctx, database)
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		_, err = applied.UpdateOne(ctx, bson.M{"_id": m.name},
+			bson.M{"$setOnInsert": bson.M{"applied_at": time.Now()}}, options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("recording migration %s: %w", m.name, err)
+		}
+		slog.InfoContext(ctx, "Applied migration", "migration", m.name, "modified", modified)
+	}
+	return nil
+}
+
+// backfillCreatedAt sets created_at on accounts registered before it was recorded,
+// from the insert time carried by their ObjectID, so that filtering and sorting on it
+// covers every user. Such accounts lack the field, or have the zero time once saved
+// again.
+func backfillCreatedAt(ctx context.Context, database *mongo.Database) (int64, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$exists": false}},
+		bson.M{"created_at": bson.M{"$lte": time.Unix(0, 0)}},
+	}}
+	result, err := database.Collection("users").UpdateMany(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// dropSentOutboxPayloads drops the payload of messages published before it was
+// dropped on publishing, as for emails it holds links with one-time tokens.
+func dropSentOutboxPayloads(ctx context.Context, database *mongo.Database) (int64, error) {
+	result, err := database.Collection("outbox").UpdateMany(ctx,
+		bson.M{"sent_at": bson.M{"$ne": nil}, "payload": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"payload": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
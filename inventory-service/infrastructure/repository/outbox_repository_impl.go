@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/db"
+	"inventory-service/infrastructure/tracing"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sentOutboxRetention is how long published messages are kept for troubleshooting.
+// Their payload is dropped as soon as they are published, as for emails it holds
+// links with one-time tokens.
+const sentOutboxRetention = 7 * 24 * time.Hour
+
+type OutboxRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(client *db.MongoClient, dbName, collectionName string) domain.OutboxRepository {
+	collection := client.Client.Database(dbName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sent_at", Value: 1}, {Key: "locked_until", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "sent_at", Value: 1}}, Options: options.Index().
+			SetExpireAfterSeconds(int32(sentOutboxRetention.Seconds())).
+			SetName("sent_at_ttl")},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create outbox indexes", "error", err)
+	}
+
+	return &OutboxRepositoryImpl{collection: collection}
+}
+
+// Add stores msg with the trace context of ctx in its headers, so that the trace
+// continues when the relay publishes it.
+func (r *OutboxRepositoryImpl) Add(ctx context.Context, msg *models.OutboxMessage) error {
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	tracing.Inject(ctx, msg.Headers)
+	result, err := r.collection.InsertOne(ctx, msg)
+	if err != nil {
+		return err
+	}
+	msg.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *OutboxRepositoryImpl) ClaimNext(ctx context.Context, lease time.Duration) (*models.OutboxMessage, error) {
+	now := time.Now()
+	filter := bson.M{
+		"sent_at":      nil,
+		"locked_until": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var msg models.OutboxMessage
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *OutboxRepositoryImpl) MarkSent(ctx context.Context, msg *models.OutboxMessage) error {
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"sent_at": now, "locked_until": now},
+		"$unset": bson.M{"payload": ""},
+		"$inc":   bson.M{"attempts": 1},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": msg.ID}, update)
+	return err
+}
+
+func (r *OutboxRepositoryImpl) MarkFailed(ctx context.Context, msg *models.OutboxMessage, cause error) error {
+	update := bson.M{
+		"$set": bson.M{"last_error": cause.Error(), "locked_until": time.Now()},
+		"$inc": bson.M{"attempts": 1},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": msg.ID}, update)
+	return err
+}
+
+// pendingEmailsFilter matches the unsent messages whose payload is addressed to an
+// email address, as encoded by json.Marshal.
+func pendingEmailsFilter(to string) (bson.M, error) {
+	encoded, err := json.Marshal(to)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{
+		"sent_at": nil,
+		"payload": primitive.Regex{Pattern: regexp.QuoteMeta(`"to":` + string(encoded))},
+	}, nil
+}
+
+func (r *OutboxRepositoryImpl) FindPendingEmails(ctx context.Context, to string) ([]*models.OutboxMessage, error) {
+	filter, err := pendingEmailsFilter(to)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	messages := []*models.OutboxMessage{}
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *OutboxRepositoryImpl) DeletePendingEmails(ctx context.Context, to string) error {
+	filter, err := pendingEmailsFilter(to)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.DeleteMany(ctx, filter)
+	return err
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/infrastructure/db"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// processedEventRetention outlives the Kafka retention period, after which an event
+// can no longer be redelivered.
+const processedEventRetention = 30 * 24 * time.Hour
+
+type ProcessedEventRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewProcessedEventRepository(client *db.MongoClient, dbName, collectionName string) domain.ProcessedEventRepository {
+	collection := client.Client.Database(dbName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "processed_at", Value: 1}},
+		Options: options.Index().
+			SetExpireAfterSeconds(int32(processedEventRetention.Seconds())).
+			SetName("processed_at_ttl"),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create processed event indexes", "error", err)
+	}
+
+	return &ProcessedEventRepositoryImpl{collection: collection}
+}
+
+func (r *ProcessedEventRepositoryImpl) Exists(ctx context.Context, eventID string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": eventID}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (r *ProcessedEventRepositoryImpl) Add(ctx context.Context, eventID, eventType string) error {
+	_, err := r.collection.InsertOne(ctx, bson.M{
+		"_id":          eventID,
+		"type":         eventType,
+		"processed_at": time.Now(),
+	})
+	return err
+}
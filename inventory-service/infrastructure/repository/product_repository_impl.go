@@ -9,6 +9,7 @@ import (
 	"inventory-service/infrastructure/cache"
 	"inventory-service/infrastructure/db"
 	"inventory-service/infrastructure/lock"
+	"inventory-service/infrastructure/metrics"
 	"sort"
 	"strings"
 	"time"
@@ -36,80 +37,91 @@ func NewProductRepository(client *db.MongoClient, dbName, collection string, red
 
 const cacheTTL = 10 * time.Minute
 
+// getLock returns the lock guarding a write. It is released with
+// context.WithoutCancel, so that a cancelled request does not leave it held until it
+// expires.
 func (r *ProductRepositoryImpl) getLock(ctx context.Context, operation, id string) *lock.DistributedLock {
 	return lock.NewDistributedLock(r.redis.Client, fmt.Sprintf("lock:product:%s:%s", operation, id), "lock-value", 30*time.Second)
 }
 
-func (r *ProductRepositoryImpl) Create(product *models.Product) error {
-	ctx := context.Background()
+func (r *ProductRepositoryImpl) Create(ctx context.Context, product *models.Product) error {
 	lock := r.getLock(ctx, "create", product.ID.Hex())
 	acquired, err := lock.Acquire(ctx)
 	if err != nil || !acquired {
-		return fmt.Errorf("failed to acquire lock: %v", err)
+		return lockError("product", err)
 	}
-	defer lock.Release(ctx)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	_, err = coll.InsertOne(ctx, product)
+	result, err := coll.InsertOne(ctx, product)
 	if err != nil {
-		return err
+		return writeError(err, "product already exists")
 	}
+	product.ID = result.InsertedID.(primitive.ObjectID)
 	r.redis.DeleteCache(ctx, "products:all")
 	return nil
 }
 
-func (r *ProductRepositoryImpl) Update(product *models.Product) error {
-	ctx := context.Background()
+func (r *ProductRepositoryImpl) Update(ctx context.Context, product *models.Product) error {
 	lock := r.getLock(ctx, "update", product.ID.Hex())
 	acquired, err := lock.Acquire(ctx)
 	if err != nil || !acquired {
-		return fmt.Errorf("failed to acquire lock: %v", err)
+		return lockError("product", err)
 	}
-	defer lock.Release(ctx)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	filter := bson.M{"_id": product.ID}
 	update := bson.M{"$set": product}
-	_, err = coll.UpdateOne(ctx, filter, update)
+	result, err := coll.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return writeError(err, "product already exists")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrProductNotFound
 	}
 	r.redis.DeleteCache(ctx, "products:all")
 	r.redis.DeleteCache(ctx, fmt.Sprintf("product:%s", product.ID.Hex()))
 	return nil
 }
 
-func (r *ProductRepositoryImpl) Delete(id string) error {
-	ctx := context.Background()
+func (r *ProductRepositoryImpl) Delete(ctx context.Context, id string) error {
 	lock := r.getLock(ctx, "delete", id)
 	acquired, err := lock.Acquire(ctx)
 	if err != nil || !acquired {
-		return fmt.Errorf("failed to acquire lock: %v", err)
+		return lockError("product", err)
 	}
-	defer lock.Release(ctx)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	objID, _ := primitive.ObjectIDFromHex(id)
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrProductNotFound
+	}
 	filter := bson.M{"_id": objID}
-	_, err = coll.DeleteOne(ctx, filter)
+	result, err := coll.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
+	if result.DeletedCount == 0 {
+		return domain.ErrProductNotFound
+	}
 	r.redis.DeleteCache(ctx, "products:all")
 	r.redis.DeleteCache(ctx, fmt.Sprintf("product:%s", id))
 	return nil
 }
 
-func (r *ProductRepositoryImpl) FindByID(id string) (*models.Product, error) {
-	ctx := context.Background()
+func (r *ProductRepositoryImpl) FindByID(ctx context.Context, id string) (*models.Product, error) {
 	cacheKey := fmt.Sprintf("product:%s", id)
 
 	if cached, err := r.redis.GetCache(ctx, cacheKey); err == nil {
 		var product models.Product
 		if json.Unmarshal([]byte(cached), &product) == nil {
+			metrics.ObserveCache("product", true)
 			return &product, nil
 		}
 	}
+	metrics.ObserveCache("product", false)
 
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	objID, _ := primitive.ObjectIDFromHex(id)
@@ -140,6 +152,7 @@ func (r *ProductRepositoryImpl) FindAll(ctx context.Context, filter domain.Produ
 		}
 	}
 
+	metrics.ObserveCache("product", allProducts != nil)
 	if allProducts == nil { // Cache miss or invalid cache
 		coll := r.client.Database(r.dbName).Collection(r.collection)
 		cursor, err := coll.Find(ctx, bson.M{}) // Fetch all products without filter initially
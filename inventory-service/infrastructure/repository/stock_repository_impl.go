@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type StockRepositoryImpl struct {
@@ -27,33 +28,109 @@ func NewStockRepository(client *db.MongoClient, dbName, collectionName string, r
 func (r *StockRepositoryImpl) BulkUpdateStock(ctx context.Context, updates map[string]struct {
 	Quantity  int
 	Increment bool
-}) error {
-	var operations []mongo.WriteModel
-	for productID, update := range updates {
+}) (map[string]int, error) {
+	objIDs := make(map[string]primitive.ObjectID, len(updates))
+	for productID := range updates {
 		objID, err := primitive.ObjectIDFromHex(productID)
 		if err != nil {
-			return err
+			return nil, domain.NewValidationError(fmt.Sprintf("invalid product ID %q", productID))
 		}
+		objIDs[productID] = objID
+	}
+
+	defer r.redis.DeleteCache(ctx, "products:all")
+
+	// Each product is updated on its own so that its new level can be read back
+	// atomically; callers run the whole batch in a transaction where supported
+	levels := make(map[string]int, len(updates))
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"stock": 1})
+	for productID, update := range updates {
 		// Adjust stock based on increment flag
 		stockChange := -update.Quantity
 		if update.Increment {
 			stockChange = update.Quantity
 		}
-		operation := mongo.NewUpdateOneModel().
-			SetFilter(bson.M{"_id": objID}).
-			SetUpdate(bson.M{"$inc": bson.M{"stock": stockChange}})
-		operations = append(operations, operation)
+		var updated struct {
+			Stock int `bson:"stock"`
+		}
+		err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objIDs[productID]}, bson.M{"$inc": bson.M{"stock": stockChange}}, opts).Decode(&updated)
 		r.redis.DeleteCache(ctx, fmt.Sprintf("product:%s", productID))
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		levels[productID] = updated.Stock
 	}
+	return levels, nil
+}
 
-	r.redis.DeleteCache(ctx, "products:all")
-
-	if len(operations) == 0 {
-		return nil
+func (r *StockRepositoryImpl) ReserveStock(ctx context.Context, productID string, quantity int) (int, bool, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return 0, false, err
 	}
+	filter := bson.M{"_id": objID, "stock": bson.M{"$gte": quantity}}
+	update := bson.M{"$inc": bson.M{"stock": -quantity, "reserved": quantity}}
+	return r.updateOne(ctx, productID, filter, update)
+}
 
-	_, err := r.collection.BulkWrite(ctx, operations)
+func (r *StockRepositoryImpl) AdjustStock(ctx context.Context, productID string, stockDelta, reservedDelta int) (int, bool, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return 0, false, err
+	}
+	update := bson.M{"$inc": bson.M{"stock": stockDelta, "reserved": reservedDelta}}
+	return r.updateOne(ctx, productID, bson.M{"_id": objID}, update)
+}
 
+// updateOne applies update to the product matching filter and returns its new stock
+// level, or false when nothing matched.
+func (r *StockRepositoryImpl) updateOne(ctx context.Context, productID string, filter, update bson.M) (int, bool, error) {
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"stock": 1})
+	var updated struct {
+		Stock int `bson:"stock"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	r.redis.DeleteCache(ctx, fmt.Sprintf("product:%s", productID))
 	r.redis.DeleteCache(ctx, "products:all")
-	return err
+	return updated.Stock, true, nil
+}
+
+func (r *StockRepositoryImpl) Summary(ctx context.Context) (int, int, error) {
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"units": bson.M{"$sum": bson.M{"$max": bson.A{"$stock", 0}}},
+			"out_of_stock": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$lte": bson.A{"$stock", 0}}, 1, 0},
+			}},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var summary struct {
+		Units      int `bson:"units"`
+		OutOfStock int `bson:"out_of_stock"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&summary); err != nil {
+			return 0, 0, err
+		}
+	}
+	return summary.Units, summary.OutOfStock, cursor.Err()
 }
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
+	"inventory-service/infrastructure/db"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StockReservationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewStockReservationRepository(client *db.MongoClient, dbName, collectionName string) domain.StockReservationRepository {
+	collection := client.Client.Database(dbName).Collection(collectionName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "order_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create stock reservation indexes", "error", err)
+	}
+
+	return &StockReservationRepositoryImpl{collection: collection}
+}
+
+func (r *StockReservationRepositoryImpl) FindByOrderID(ctx context.Context, orderID string) (*models.StockReservation, error) {
+	var reservation models.StockReservation
+	err := r.collection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&reservation)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r *StockReservationRepositoryImpl) Save(ctx context.Context, reservation *models.StockReservation) error {
+	filter := bson.M{"order_id": reservation.OrderID}
+	// The _id is left out of the replacement so that it is kept, or generated on insert
+	doc := *reservation
+	doc.ID = primitive.NilObjectID
+	_, err := r.collection.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return err
+}
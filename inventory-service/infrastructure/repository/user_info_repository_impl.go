@@ -5,10 +5,14 @@ import (
 	"inventory-service/domain"
 	"inventory-service/domain/models"
 	"inventory-service/infrastructure/db"
+	"log/slog"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserInfoRepositoryImpl struct {
@@ -16,56 +20,124 @@ type UserInfoRepositoryImpl struct {
 }
 
 func NewUserInfoRepository(client *db.MongoClient, dbName, collectionName string) domain.UserInfoRepository {
-	return &UserInfoRepositoryImpl{collection: client.Client.Database(dbName).Collection(collectionName)}
+	collection := client.Client.Database(dbName).Collection(collectionName)
+
+	// Lets concurrent sign-ups with the same address fail with a conflict
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("email_unique"),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create user indexes", "error", err)
+	}
+
+	return &UserInfoRepositoryImpl{collection: collection}
 }
 
 func (r *UserInfoRepositoryImpl) GetByID(ctx context.Context, id string) (*models.User, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, domain.ErrUserNotFound
 	}
 	var user models.User
 	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserInfoRepositoryImpl) GetAll(ctx context.Context) ([]*models.User, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+var sortableUserFields = map[string]bool{"email": true, "role": true, "created_at": true, "last_login_at": true}
+
+func (r *UserInfoRepositoryImpl) GetAll(ctx context.Context, filter domain.UserFilter, sortOpt domain.UserSort, page, limit int) ([]*models.User, int64, error) {
+	query := bson.M{}
+	if filter.Email != "" {
+		query["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Email), "$options": "i"}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.IsVerified != nil {
+		query["is_verified"] = *filter.IsVerified
+	}
+	if !filter.CreatedFrom.IsZero() || !filter.CreatedTo.IsZero() {
+		created := bson.M{}
+		if !filter.CreatedFrom.IsZero() {
+			created["$gte"] = filter.CreatedFrom
+		}
+		if !filter.CreatedTo.IsZero() {
+			created["$lte"] = filter.CreatedTo
+		}
+		query["created_at"] = created
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	var users []*models.User
+
+	// Sort on _id as a tiebreaker so pages are stable
+	sortDoc := bson.D{}
+	if sortableUserFields[sortOpt.Field] {
+		sortDoc = append(sortDoc, bson.E{Key: sortOpt.Field, Value: sortOpt.Order})
+	}
+	sortDoc = append(sortDoc, bson.E{Key: "_id", Value: 1})
+
+	findOpts := options.Find().SetSort(sortDoc)
+	if limit > 0 {
+		if page < 1 {
+			page = 1
+		}
+		findOpts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := []*models.User{}
 	if err = cursor.All(ctx, &users); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return users, nil
+	return users, total, nil
 }
 
 func (r *UserInfoRepositoryImpl) Update(ctx context.Context, id string, user *models.User) (*models.User, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, domain.ErrUserNotFound
 	}
 	update := bson.M{"$set": bson.M{
 		"email":       user.Email,
 		"role":        user.Role,
 		"is_verified": user.IsVerified,
 	}}
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 	if err != nil {
-		return nil, err
+		return nil, writeError(err, "email address is already in use")
+	}
+	if result.MatchedCount == 0 {
+		return nil, domain.ErrUserNotFound
 	}
 	return r.GetByID(ctx, id)
 }
 
 func (r *UserInfoRepositoryImpl) Delete(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
 	if err != nil {
 		return err
 	}
-	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objID})
-	return err
+	if result.DeletedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
 }
@@ -25,63 +25,89 @@ func NewUserRepository(client *db.MongoClient, dbName, collection string) domain
 	}
 }
 
-func (r *UserRepositoryImpl) Create(user *models.User) error {
+func (r *UserRepositoryImpl) Create(ctx context.Context, user *models.User) error {
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	_, err := coll.InsertOne(context.Background(), user)
-	return err
+	_, err := coll.InsertOne(ctx, user)
+	return writeError(err, "email address is already in use")
 }
 
-func (r *UserRepositoryImpl) FindByEmail(email string) (*models.User, error) {
+func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	filter := bson.M{"email": email}
 
 	var user models.User
-	err := coll.FindOne(context.Background(), filter).Decode(&user)
+	err := coll.FindOne(ctx, filter).Decode(&user)
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
 	return &user, err
 }
 
-func (r *UserRepositoryImpl) FindByID(id string) (*models.User, error) {
+func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*models.User, error) {
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	objID, _ := primitive.ObjectIDFromHex(id)
 	filter := bson.M{"_id": objID}
 
 	var user models.User
-	err := coll.FindOne(context.Background(), filter).Decode(&user)
+	err := coll.FindOne(ctx, filter).Decode(&user)
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
 	return &user, err
 }
 
-func (r *UserRepositoryImpl) Update(user *models.User) error {
+func (r *UserRepositoryImpl) Update(ctx context.Context, user *models.User) error {
 	coll := r.client.Database(r.dbName).Collection(r.collection)
 	filter := bson.M{"_id": user.ID}
-	update := bson.M{"$set": user}
-	_, err := coll.UpdateOne(context.Background(), filter, update)
+	update := bson.M{
+		"$set": user,
+		// Drop plaintext tokens left behind by documents written before tokens were hashed
+		"$unset": bson.M{"verification_token": "", "reset_token": ""},
+	}
+	_, err := coll.UpdateOne(ctx, filter, update)
+	return writeError(err, "email address is already in use")
+}
+
+func (r *UserRepositoryImpl) Delete(ctx context.Context, id string) error {
+	coll := r.client.Database(r.dbName).Collection(r.collection)
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = coll.DeleteOne(ctx, bson.M{"_id": objID})
 	return err
 }
 
-func (r *UserRepositoryImpl) FindByVerificationToken(token string) (*models.User, error) {
+func (r *UserRepositoryImpl) FindByEmailChangeTokenHash(ctx context.Context, hash string) (*models.User, error) {
+	coll := r.client.Database(r.dbName).Collection(r.collection)
+	filter := bson.M{"email_change.hash": hash}
+
+	var user models.User
+	err := coll.FindOne(ctx, filter).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &user, err
+}
+
+func (r *UserRepositoryImpl) FindByVerificationTokenHash(ctx context.Context, hash string) (*models.User, error) {
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	filter := bson.M{"verification_token": token}
+	filter := bson.M{"verification.hash": hash}
 
 	var user models.User
-	err := coll.FindOne(context.Background(), filter).Decode(&user)
+	err := coll.FindOne(ctx, filter).Decode(&user)
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
 	return &user, err
 }
 
-func (r *UserRepositoryImpl) FindByResetToken(token string) (*models.User, error) {
+func (r *UserRepositoryImpl) FindByResetTokenHash(ctx context.Context, hash string) (*models.User, error) {
 	coll := r.client.Database(r.dbName).Collection(r.collection)
-	filter := bson.M{"reset_token": token}
+	filter := bson.M{"password_reset.hash": hash}
 
 	var user models.User
-	err := coll.FindOne(context.Background(), filter).Decode(&user)
+	err := coll.FindOne(ctx, filter).Decode(&user)
 	if err == mongo.ErrNoDocuments {
 		return nil, nil
 	}
@@ -2,7 +2,7 @@ package services
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"mime/multipart"
 
 	"github.com/cloudinary/cloudinary-go/v2"
@@ -18,17 +18,14 @@ func NewCloudinaryService(cloudName, apiKey, apiSecret string) *CloudinaryServic
 	return &CloudinaryService{cld: cld}
 }
 
-func (s *CloudinaryService) UploadImage(file multipart.File) (string, error) {
-	log.Println("Uploading image...")
-	log.Println(file)
-	ctx := context.Background()
+func (s *CloudinaryService) UploadImage(ctx context.Context, file multipart.File) (string, error) {
 	resp, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		Folder: "inventory",
 	})
 	if err != nil {
-		log.Println(err)
+		slog.ErrorContext(ctx, "Failed to upload image", "error", err)
 		return "", err
 	}
-	log.Println(resp)
+	slog.DebugContext(ctx, "Uploaded image", "public_id", resp.PublicID)
 	return resp.SecureURL, nil
 }
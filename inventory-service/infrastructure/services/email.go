@@ -2,46 +2,110 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"inventory-service/domain"
+	"inventory-service/domain/models"
 	"inventory-service/infrastructure/config"
+	"inventory-service/infrastructure/mail"
 	"inventory-service/infrastructure/messaging"
+	"net/url"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// EmailService queues emails for delivery through the outbox, so an email is queued
+// if and only if the transaction in ctx commits. The locale selects the template
+// variant; an empty locale uses the default one.
 type EmailService interface {
-	SendVerificationEmail(to, token string) error
-	SendPasswordResetEmail(to, token string) error
+	SendVerificationEmail(ctx context.Context, to, locale, token string) error
+	SendPasswordResetEmail(ctx context.Context, to, locale, token string) error
+	SendEmailChangeEmail(ctx context.Context, to, locale, token string) error
+	SendInvitationEmail(ctx context.Context, to, locale, token, role string) error
+	SendLowStockEmail(ctx context.Context, to, locale string, product *models.Product, threshold int) error
 }
 
 type emailService struct {
-	kafkaProducer *messaging.KafkaProducer
-	cfg           *config.Config
+	outbox   domain.OutboxRepository
+	renderer *mail.Renderer
+	cfg      *config.Config
 }
 
-func NewEmailService(cfg *config.Config, kafkaProducer *messaging.KafkaProducer) EmailService {
+func NewEmailService(cfg *config.Config, outbox domain.OutboxRepository, renderer *mail.Renderer) EmailService {
 	return &emailService{
-		kafkaProducer: kafkaProducer,
-		cfg:           cfg,
+		outbox:   outbox,
+		renderer: renderer,
+		cfg:      cfg,
 	}
 }
 
-func (s *emailService) SendVerificationEmail(to, token string) error {
-	msg := messaging.EmailMessage{
-		Type:    "verification",
-		To:      to,
-		Token:   token,
-		Subject: "Verify Your Email",
-		Body:    fmt.Sprintf("Click the link to verify your email: http://localhost:%s/inventory/api/users/verify/%s", s.cfg.Port, token),
-	}
-	return s.kafkaProducer.SendEmailMessage(context.Background(), msg)
+func (s *emailService) SendVerificationEmail(ctx context.Context, to, locale, token string) error {
+	return s.send(ctx, "verification", to, locale, mail.TemplateVerification, map[string]interface{}{
+		"Email": to,
+		"Link":  s.link(s.cfg.EmailLinks.Verification, token),
+	})
+}
+
+func (s *emailService) SendPasswordResetEmail(ctx context.Context, to, locale, token string) error {
+	return s.send(ctx, "reset_password", to, locale, mail.TemplatePasswordReset, map[string]interface{}{
+		"Email": to,
+		"Link":  s.link(s.cfg.EmailLinks.PasswordReset, token),
+	})
+}
+
+func (s *emailService) SendEmailChangeEmail(ctx context.Context, to, locale, token string) error {
+	return s.send(ctx, "email_change", to, locale, mail.TemplateEmailChange, map[string]interface{}{
+		"Email": to,
+		"Link":  s.link(s.cfg.EmailLinks.EmailChange, token),
+	})
+}
+
+func (s *emailService) SendInvitationEmail(ctx context.Context, to, locale, token, role string) error {
+	return s.send(ctx, "invitation", to, locale, mail.TemplateInvitation, map[string]interface{}{
+		"Email": to,
+		"Role":  role,
+		"Link":  s.link(s.cfg.EmailLinks.Invitation, token),
+	})
+}
+
+func (s *emailService) SendLowStockEmail(ctx context.Context, to, locale string, product *models.Product, threshold int) error {
+	return s.send(ctx, "low_stock", to, locale, mail.TemplateLowStock, map[string]interface{}{
+		"ProductID":   product.ID.Hex(),
+		"ProductName": product.Name,
+		"Category":    product.Category,
+		"Stock":       product.Stock,
+		"Threshold":   threshold,
+	})
+}
+
+// link expands a configured link pattern for the token.
+func (s *emailService) link(pattern, token string) string {
+	return strings.NewReplacer(
+		"{ui}", s.cfg.PublicUIURL,
+		"{api}", s.cfg.PublicAPIURL,
+		"{token}", url.PathEscape(token),
+	).Replace(pattern)
 }
 
-func (s *emailService) SendPasswordResetEmail(to, token string) error {
+// send renders the template and adds the result to the outbox for the email worker.
+// One-time tokens only travel within the rendered links, which are dropped from the
+// outbox once published.
+func (s *emailService) send(ctx context.Context, msgType, to, locale, templateName string, data map[string]interface{}) error {
+	rendered, err := s.renderer.Render(ctx, templateName, locale, data)
+	if err != nil {
+		return err
+	}
+
 	msg := messaging.EmailMessage{
-		Type:    "reset_password",
-		To:      to,
-		Token:   token,
-		Subject: "Reset Your Password",
-		Body:    fmt.Sprintf("Click the link to reset your password: http://localhost:%s/inventory/api/users/password/reset/%s", s.cfg.Port, token),
+		ID:       primitive.NewObjectID().Hex(),
+		Type:     msgType,
+		To:       to,
+		Subject:  rendered.Subject,
+		Body:     rendered.Text,
+		HTMLBody: rendered.HTML,
+	}
+	outboxMsg, err := messaging.NewOutboxMessage(s.cfg.KafkaEmailTopic, messaging.SchemaEmail, "", msg)
+	if err != nil {
+		return err
 	}
-	return s.kafkaProducer.SendEmailMessage(context.Background(), msg)
+	return s.outbox.Add(ctx, outboxMsg)
 }
@@ -2,24 +2,48 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"inventory-service/infrastructure/config"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Instance statuses. The instance registers as starting and is then reported up or
+// out of service according to its readiness, see SetStatus.
 const (
-	eurekaServer = "http://localhost:8761/eureka"
-	appName      = "INVENTORY-SERVICE"
-	instanceID   = "inventory-service-instance-1"
-	hostName     = "localhost"
-	ipAddr       = "127.0.0.1"
-	port         = 8080
+	StatusStarting     = "STARTING"
+	StatusUp           = "UP"
+	StatusOutOfService = "OUT_OF_SERVICE"
 )
 
-// EurekaInstanceInfo holds the instance details needed for registration.
+// eurekaRequestTimeout bounds each request to a Eureka server, so that a server that
+// hangs fails over like one that refuses connections.
+const eurekaRequestTimeout = 10 * time.Second
+
+// ErrNotRegistered is returned by a heartbeat when the server does not know the
+// instance, e.g. after the server restarted or evicted it.
+var ErrNotRegistered = errors.New("instance not registered with Eureka")
+
+// ErrNoInstance is returned by Resolve when no instance of an application is up.
+var ErrNoInstance = errors.New("no instance available")
+
+// EurekaPort is a port of an instance, in the JSON shape Eureka uses.
+type EurekaPort struct {
+	Port    int    `json:"$"`
+	Enabled string `json:"@enabled"`
+}
+
+// EurekaInstanceInfo holds the instance details exchanged with Eureka.
 type EurekaInstanceInfo struct {
 	InstanceID       string                 `json:"instanceId"`
 	HostName         string                 `json:"hostName"`
@@ -28,90 +52,375 @@ type EurekaInstanceInfo struct {
 	SecureVIPAddress string                 `json:"secureVipAddress"`
 	IPAddr           string                 `json:"ipAddr"`
 	Status           string                 `json:"status"`
-	Port             map[string]interface{} `json:"port"`
-	SecurePort       map[string]interface{} `json:"securePort"`
+	Port             EurekaPort             `json:"port"`
+	SecurePort       EurekaPort             `json:"securePort"`
 	DataCenterInfo   map[string]interface{} `json:"dataCenterInfo"`
 	HomePageUrl      string                 `json:"homePageUrl"`
 	StatusPageUrl    string                 `json:"statusPageUrl"`
 	HealthCheckUrl   string                 `json:"healthCheckUrl"`
 }
 
+// BaseURL returns the address the instance serves HTTP on.
+func (i *EurekaInstanceInfo) BaseURL() string {
+	return "http://" + net.JoinHostPort(i.IPAddr, strconv.Itoa(i.Port.Port))
+}
+
 // EurekaRegistrationPayload wraps the instance info.
 type EurekaRegistrationPayload struct {
 	Instance EurekaInstanceInfo `json:"instance"`
 }
 
-// RegisterWithEureka registers the service with the Eureka server.
-func RegisterWithEureka() {
-	url := fmt.Sprintf("%s/apps/%s", eurekaServer, appName)
-	instanceInfo := EurekaInstanceInfo{
-		InstanceID:       instanceID,
-		HostName:         hostName,
-		App:              appName,
-		VIPAddress:       appName,
-		SecureVIPAddress: appName,
-		IPAddr:           ipAddr,
-		Status:           "UP",
-		Port: map[string]interface{}{
-			"$":        port,
-			"@enabled": "true",
-		},
-		SecurePort: map[string]interface{}{
-			"$":        0,
-			"@enabled": "false",
-		},
-		DataCenterInfo: map[string]interface{}{
-			"@class": "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
-			"name":   "MyOwn",
+// eurekaApplications is the registry as returned by GET /apps. Older servers send a
+// single object instead of a list when there is only one element.
+type eurekaApplications struct {
+	Applications struct {
+		Application eurekaList[eurekaApplication] `json:"application"`
+	} `json:"applications"`
+}
+
+type eurekaApplication struct {
+	Name     string                         `json:"name"`
+	Instance eurekaList[EurekaInstanceInfo] `json:"instance"`
+}
+
+type eurekaList[T any] []T
+
+func (l *eurekaList[T]) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		*l = []T{item}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]T)(l))
+}
+
+// EurekaClient registers the service with Eureka, keeps the registration alive and
+// caches the registry so that other services can be resolved. Requests go to the
+// first server of the list that answers, starting from the last one that did.
+type EurekaClient struct {
+	servers           []string
+	instance          EurekaInstanceInfo
+	heartbeatInterval time.Duration
+	fetchInterval     time.Duration
+	http              *http.Client
+
+	mu       sync.Mutex
+	status   string
+	current  int // Index in servers of the server last answering
+	registry map[string][]EurekaInstanceInfo
+	next     map[string]int // Round-robin position per application, for Resolve
+}
+
+// NewEurekaClient describes this instance from the configuration. The host name
+// defaults to the machine's, which is the pod name on Kubernetes, and the IP
+// address to the first non-loopback address.
+func NewEurekaClient(cfg *config.Config) *EurekaClient {
+	hostName := cfg.EurekaHostName
+	if hostName == "" {
+		hostName, _ = os.Hostname()
+	}
+	if hostName == "" {
+		hostName = "localhost"
+	}
+	ipAddr := cfg.EurekaIPAddr
+	if ipAddr == "" {
+		ipAddr = localIP()
+	}
+	port, _ := strconv.Atoi(cfg.Port)
+	appName := strings.ToUpper(cfg.EurekaAppName)
+	instanceID := cfg.EurekaInstanceID
+	if instanceID == "" {
+		// The format Spring Cloud uses, unique per pod and port
+		instanceID = fmt.Sprintf("%s:%s:%d", hostName, strings.ToLower(appName), port)
+	}
+	// Pod names do not resolve outside their pod, so the advertised URLs use the IP
+	baseURL := "http://" + net.JoinHostPort(ipAddr, cfg.Port)
+
+	servers := make([]string, len(cfg.EurekaServerURLs))
+	for i, server := range cfg.EurekaServerURLs {
+		servers[i] = strings.TrimRight(server, "/")
+	}
+
+	return &EurekaClient{
+		servers: servers,
+		instance: EurekaInstanceInfo{
+			InstanceID:       instanceID,
+			HostName:         hostName,
+			App:              appName,
+			VIPAddress:       strings.ToLower(appName),
+			SecureVIPAddress: strings.ToLower(appName),
+			IPAddr:           ipAddr,
+			Port:             EurekaPort{Port: port, Enabled: "true"},
+			SecurePort:       EurekaPort{Port: 443, Enabled: "false"},
+			DataCenterInfo: map[string]interface{}{
+				"@class": "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
+				"name":   "MyOwn",
+			},
+			HomePageUrl:    baseURL + "/",
+			StatusPageUrl:  baseURL + "/info",
+			HealthCheckUrl: baseURL + "/health/ready",
 		},
-		HomePageUrl:    fmt.Sprintf("http://%s:%d/", hostName, port),
-		StatusPageUrl:  fmt.Sprintf("http://%s:%d/info", hostName, port),
-		HealthCheckUrl: fmt.Sprintf("http://%s:%d/health", hostName, port),
+		heartbeatInterval: cfg.EurekaHeartbeatInterval,
+		fetchInterval:     cfg.EurekaRegistryFetchInterval,
+		http:              &http.Client{Timeout: eurekaRequestTimeout},
+		status:            StatusStarting,
+		registry:          make(map[string][]EurekaInstanceInfo),
+		next:              make(map[string]int),
+	}
+}
+
+func localIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+				return ipNet.IP.String()
+			}
+		}
+	}
+	return "127.0.0.1"
+}
+
+// InstanceID returns the ID the instance registers under.
+func (c *EurekaClient) InstanceID() string {
+	return c.instance.InstanceID
+}
+
+// Register registers the instance with its current status.
+func (c *EurekaClient) Register(ctx context.Context) error {
+	c.mu.Lock()
+	instance := c.instance
+	instance.Status = c.status
+	c.mu.Unlock()
+
+	data, err := json.Marshal(EurekaRegistrationPayload{Instance: instance})
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, "POST", "/apps/"+instance.App, data)
+	if err != nil {
+		return fmt.Errorf("registering with Eureka: %w", err)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registering with Eureka: %s", resp.Status)
+	}
+	slog.InfoContext(ctx, "Registered with Eureka", "instance_id", instance.InstanceID, "status", instance.Status)
+	return nil
+}
+
+// Run sends heartbeats and refreshes the cached registry until ctx is done. An
+// instance the server does not know, e.g. because Register failed, is registered on
+// the next heartbeat. Run does not deregister; see Deregister.
+func (c *EurekaClient) Run(ctx context.Context) {
+	heartbeat := time.NewTicker(c.heartbeatInterval)
+	defer heartbeat.Stop()
+	fetch := time.NewTicker(c.fetchInterval)
+	defer fetch.Stop()
+
+	c.fetchRegistry(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			c.heartbeat(ctx)
+		case <-fetch.C:
+			c.fetchRegistry(ctx)
+		}
 	}
+}
+
+// heartbeat renews the lease of the instance, registering it again when the server
+// no longer knows it.
+func (c *EurekaClient) heartbeat(ctx context.Context) {
+	err := c.SendHeartbeat(ctx)
+	if errors.Is(err, ErrNotRegistered) {
+		slog.WarnContext(ctx, "Eureka lost the registration, registering again", "instance_id", c.instance.InstanceID)
+		err = c.Register(ctx)
+	}
+	if err != nil && ctx.Err() == nil {
+		slog.WarnContext(ctx, "Eureka heartbeat failed", "error", err)
+	}
+}
+
+// SendHeartbeat renews the lease of the instance once.
+func (c *EurekaClient) SendHeartbeat(ctx context.Context) error {
+	c.mu.Lock()
+	status := c.status
+	c.mu.Unlock()
 
-	payload := EurekaRegistrationPayload{Instance: instanceInfo}
-	data, err := json.Marshal(payload)
+	path := fmt.Sprintf("/apps/%s/%s?status=%s&lastDirtyTimestamp=%d", c.instance.App, c.instance.InstanceID, status, time.Now().UnixMilli())
+	resp, err := c.do(ctx, "PUT", path, nil)
 	if err != nil {
-		log.Fatalf("Error marshalling registration payload: %v", err)
+		return err
 	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrNotRegistered
+	default:
+		return fmt.Errorf("heartbeat: %s", resp.Status)
+	}
+}
+
+// SetStatus overrides the status of the instance, e.g. to take it out of service
+// while a dependency is down without deregistering it. The status is kept for
+// heartbeats and later registrations even when the server cannot be told.
+func (c *EurekaClient) SetStatus(ctx context.Context, status string) error {
+	c.mu.Lock()
+	c.status = status
+	c.mu.Unlock()
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+	path := fmt.Sprintf("/apps/%s/%s/status?value=%s", c.instance.App, c.instance.InstanceID, status)
+	resp, err := c.do(ctx, "PUT", path, nil)
 	if err != nil {
-		log.Fatalf("Error creating registration request: %v", err)
+		return fmt.Errorf("setting Eureka status to %s: %w", status, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	if resp.StatusCode == http.StatusNotFound {
+		// Not registered yet; registering sends the new status
+		return c.Register(ctx)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("setting Eureka status to %s: %s", status, resp.Status)
+	}
+	slog.InfoContext(ctx, "Eureka status set", "status", status)
+	return nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// Deregister removes the instance from the registry, so that clients stop being
+// routed to it.
+func (c *EurekaClient) Deregister(ctx context.Context) error {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf("/apps/%s/%s", c.instance.App, c.instance.InstanceID), nil)
 	if err != nil {
-		log.Printf("Error registering with Eureka: %v", err)
-		return
+		return fmt.Errorf("deregistering from Eureka: %w", err)
 	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	log.Printf("Registration response: %d %s", resp.StatusCode, string(body))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("deregistering from Eureka: %s", resp.Status)
+	}
+	slog.InfoContext(ctx, "Deregistered from Eureka", "instance_id", c.instance.InstanceID)
+	return nil
 }
 
-// SendHeartbeat periodically sends a heartbeat (PUT) to Eureka.
-func SendHeartbeat() {
-	for {
-		// Sleep for 30 seconds before sending the next heartbeat.
-		time.Sleep(30 * time.Second)
-		timestamp := time.Now().UnixNano() / int64(time.Millisecond)
-		url := fmt.Sprintf("%s/apps/%s/%s?status=UP&lastDirtyTimestamp=%d", eurekaServer, appName, instanceID, timestamp)
-		req, err := http.NewRequest("PUT", url, nil)
-		if err != nil {
-			log.Printf("Error creating heartbeat request: %v", err)
-			continue
+// fetchRegistry replaces the cached registry; on failure the previous one is kept.
+func (c *EurekaClient) fetchRegistry(ctx context.Context) {
+	if err := c.FetchRegistry(ctx); err != nil && ctx.Err() == nil {
+		slog.WarnContext(ctx, "Failed to fetch the Eureka registry", "error", err)
+	}
+}
+
+// FetchRegistry replaces the cached registry with the server's.
+func (c *EurekaClient) FetchRegistry(ctx context.Context) error {
+	resp, err := c.do(ctx, "GET", "/apps", nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching registry: %s", resp.Status)
+	}
+	var apps eurekaApplications
+	if err := json.Unmarshal(resp.Body, &apps); err != nil {
+		return fmt.Errorf("decoding registry: %w", err)
+	}
+
+	registry := make(map[string][]EurekaInstanceInfo)
+	for _, app := range apps.Applications.Application {
+		registry[strings.ToUpper(app.Name)] = app.Instance
+	}
+	c.mu.Lock()
+	c.registry = registry
+	c.mu.Unlock()
+	return nil
+}
+
+// Instances returns the instances of an application that are up, as of the last
+// registry fetch.
+func (c *EurekaClient) Instances(app string) []EurekaInstanceInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var up []EurekaInstanceInfo
+	for _, instance := range c.registry[strings.ToUpper(app)] {
+		if instance.Status == StatusUp {
+			up = append(up, instance)
+		}
+	}
+	return up
+}
+
+// Resolve returns the base URL of an instance of an application that is up, taking
+// the instances in turn.
+func (c *EurekaClient) Resolve(app string) (string, error) {
+	instances := c.Instances(app)
+	if len(instances) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNoInstance, app)
+	}
+	c.mu.Lock()
+	key := strings.ToUpper(app)
+	i := c.next[key] % len(instances)
+	c.next[key] = i + 1
+	c.mu.Unlock()
+	return instances[i].BaseURL(), nil
+}
+
+// eurekaResponse is a response whose body has been read, so that callers cannot
+// leak it.
+type eurekaResponse struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+// do sends a request to the servers in turn, starting from the last one that
+// answered, until one answers without a server error.
+func (c *EurekaClient) do(ctx context.Context, method, path string, body []byte) (*eurekaResponse, error) {
+	if len(c.servers) == 0 {
+		return nil, errors.New("no Eureka server configured")
+	}
+	c.mu.Lock()
+	start := c.current
+	c.mu.Unlock()
+
+	var lastErr error
+	for n := 0; n < len(c.servers); n++ {
+		i := (start + n) % len(c.servers)
+		resp, err := c.send(ctx, method, c.servers[i]+path, body)
+		if err == nil && resp.StatusCode < 500 {
+			c.mu.Lock()
+			c.current = i
+			c.mu.Unlock()
+			return resp, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s", resp.Status)
 		}
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("Error sending heartbeat: %v", err)
-			continue
+		lastErr = fmt.Errorf("%s: %w", c.servers[i], err)
+		if ctx.Err() != nil {
+			break
 		}
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Heartbeat response: %d %s", resp.StatusCode, string(body))
 	}
+	return nil, lastErr
+}
+
+func (c *EurekaClient) send(ctx context.Context, method, url string, body []byte) (*eurekaResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &eurekaResponse{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}, nil
 }
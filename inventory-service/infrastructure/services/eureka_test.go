@@ -0,0 +1,210 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"inventory-service/infrastructure/config"
+	"inventory-service/infrastructure/services"
+	"inventory-service/infrastructure/services/eurekatest"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	app        = "INVENTORY-SERVICE"
+	instanceID = "inventory-0:inventory-service:8080"
+)
+
+func newClient(servers ...string) *services.EurekaClient {
+	return services.NewEurekaClient(&config.Config{
+		Port:                        "8080",
+		EurekaServerURLs:            servers,
+		EurekaAppName:               app,
+		EurekaInstanceID:            instanceID,
+		EurekaHostName:              "inventory-0",
+		EurekaIPAddr:                "10.0.0.5",
+		EurekaHeartbeatInterval:     10 * time.Millisecond,
+		EurekaRegistryFetchInterval: 10 * time.Millisecond,
+	})
+}
+
+// run runs the client until the test ends.
+func run(t *testing.T, client *services.EurekaClient) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		client.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEurekaFailover(t *testing.T) {
+	// Refuses connections
+	down := httptest.NewServer(nil)
+	down.Close()
+	failing := eurekatest.NewServer()
+	defer failing.Close()
+	failing.SetFailing(true)
+	healthy := eurekatest.NewServer()
+	defer healthy.Close()
+
+	client := newClient(down.URL+"/eureka", failing.URL(), healthy.URL())
+	ctx := context.Background()
+	if err := client.Register(ctx); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	instance, ok := healthy.Instance(app, instanceID)
+	if !ok {
+		t.Fatal("instance not registered with the healthy server")
+	}
+	if instance.Status != services.StatusStarting {
+		t.Errorf("status = %s, want %s", instance.Status, services.StatusStarting)
+	}
+	if instance.HealthCheckUrl != "http://10.0.0.5:8080/health/ready" {
+		t.Errorf("health check URL = %s", instance.HealthCheckUrl)
+	}
+
+	// Requests stick to the server that answered
+	if err := client.SendHeartbeat(ctx); err != nil {
+		t.Fatalf("SendHeartbeat: %v", err)
+	}
+	if n := healthy.Heartbeats(instanceID); n != 1 {
+		t.Errorf("healthy server got %d heartbeats, want 1", n)
+	}
+
+	healthy.SetFailing(true)
+	err := client.SendHeartbeat(ctx)
+	if err == nil {
+		t.Fatal("SendHeartbeat succeeded with every server down")
+	}
+	if errors.Is(err, services.ErrNotRegistered) {
+		t.Errorf("SendHeartbeat = %v, want a server error", err)
+	}
+}
+
+func TestEurekaReregistersAfterHeartbeat404(t *testing.T) {
+	server := eurekatest.NewServer()
+	defer server.Close()
+
+	client := newClient(server.URL())
+	ctx := context.Background()
+	if err := client.Register(ctx); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := client.SetStatus(ctx, services.StatusUp); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+
+	server.Evict(app, instanceID)
+	if err := client.SendHeartbeat(ctx); !errors.Is(err, services.ErrNotRegistered) {
+		t.Fatalf("SendHeartbeat after eviction = %v, want ErrNotRegistered", err)
+	}
+
+	run(t, client)
+	waitFor(t, "the instance to register again", func() bool {
+		_, ok := server.Instance(app, instanceID)
+		return ok && server.Heartbeats(instanceID) > 0
+	})
+	instance, _ := server.Instance(app, instanceID)
+	if instance.Status != services.StatusUp {
+		t.Errorf("status after registering again = %s, want %s", instance.Status, services.StatusUp)
+	}
+}
+
+func TestEurekaDeregister(t *testing.T) {
+	server := eurekatest.NewServer()
+	defer server.Close()
+
+	client := newClient(server.URL())
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := client.Register(ctx); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Run(ctx)
+	}()
+	waitFor(t, "a heartbeat", func() bool { return server.Heartbeats(instanceID) > 0 })
+
+	// On shutdown the heartbeats stop first, then the instance deregisters
+	cancel()
+	<-done
+	if err := client.Deregister(context.Background()); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+	if _, ok := server.Instance(app, instanceID); ok {
+		t.Error("instance still registered after Deregister")
+	}
+
+	// An instance the server already forgot counts as deregistered
+	if err := client.Deregister(context.Background()); err != nil {
+		t.Errorf("Deregister when not registered: %v", err)
+	}
+}
+
+func TestEurekaResolve(t *testing.T) {
+	server := eurekatest.NewServer()
+	defer server.Close()
+	for _, instance := range []services.EurekaInstanceInfo{
+		{InstanceID: "orders-0", App: "ORDER-SERVICE", IPAddr: "10.0.1.1", Status: services.StatusUp, Port: services.EurekaPort{Port: 8081}},
+		{InstanceID: "orders-1", App: "ORDER-SERVICE", IPAddr: "10.0.1.2", Status: services.StatusUp, Port: services.EurekaPort{Port: 8081}},
+		{InstanceID: "orders-2", App: "ORDER-SERVICE", IPAddr: "10.0.1.3", Status: services.StatusOutOfService, Port: services.EurekaPort{Port: 8081}},
+		{InstanceID: "users-0", App: "USER-SERVICE", IPAddr: "10.0.2.1", Status: services.StatusStarting, Port: services.EurekaPort{Port: 8082}},
+	} {
+		server.Add(instance)
+	}
+
+	client := newClient(server.URL())
+	if _, err := client.Resolve("order-service"); !errors.Is(err, services.ErrNoInstance) {
+		t.Fatalf("Resolve before fetching = %v, want ErrNoInstance", err)
+	}
+	if err := client.FetchRegistry(context.Background()); err != nil {
+		t.Fatalf("FetchRegistry: %v", err)
+	}
+
+	if instances := client.Instances("order-service"); len(instances) != 2 {
+		t.Errorf("got %d instances up, want 2", len(instances))
+	}
+	resolved := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		url, err := client.Resolve("order-service")
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		resolved[url]++
+	}
+	if resolved["http://10.0.1.1:8081"] != 2 || resolved["http://10.0.1.2:8081"] != 2 {
+		t.Errorf("Resolve did not take the instances up in turn: %v", resolved)
+	}
+	if _, err := client.Resolve("user-service"); !errors.Is(err, services.ErrNoInstance) {
+		t.Errorf("Resolve with no instance up = %v, want ErrNoInstance", err)
+	}
+
+	// The registry is refreshed while running
+	server.Add(services.EurekaInstanceInfo{InstanceID: "users-0", App: "USER-SERVICE", IPAddr: "10.0.2.1", Status: services.StatusUp, Port: services.EurekaPort{Port: 8082}})
+	run(t, client)
+	waitFor(t, "the registry to be refreshed", func() bool {
+		url, err := client.Resolve("user-service")
+		return err == nil && url == "http://10.0.2.1:8082"
+	})
+}
@@ -0,0 +1,169 @@
+// Package eurekatest provides a fake Eureka server for exercising the Eureka client
+// without a real registry.
+package eurekatest
+
+import (
+	"encoding/json"
+	"inventory-service/infrastructure/services"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Server is an in-memory Eureka registry serving the subset of the REST API the
+// client uses: register, heartbeat, status override, deregister and fetching all
+// applications. It can be made to fail, and instances evicted, to exercise failover
+// and re-registration.
+type Server struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	apps       map[string]map[string]*services.EurekaInstanceInfo // By application and instance ID
+	heartbeats map[string]int                                     // By instance ID
+	failing    bool
+}
+
+// NewServer starts a fake server; Close stops it.
+func NewServer() *Server {
+	s := &Server{
+		apps:       make(map[string]map[string]*services.EurekaInstanceInfo),
+		heartbeats: make(map[string]int),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the service URL to configure the client with, e.g.
+// http://127.0.0.1:41234/eureka.
+func (s *Server) URL() string {
+	return s.server.URL + "/eureka"
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// SetFailing makes every request fail with 503 Service Unavailable, or stops doing so.
+func (s *Server) SetFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+// Add registers an instance, e.g. of another service for the client to resolve.
+func (s *Server) Add(instance services.EurekaInstanceInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(&instance)
+}
+
+// Evict forgets an instance, as the server does when its lease expires or it
+// restarts; the next heartbeat of the instance gets 404 Not Found.
+func (s *Server) Evict(app, instanceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.apps[strings.ToUpper(app)], instanceID)
+}
+
+// Instance returns a copy of a registered instance.
+func (s *Server) Instance(app, instanceID string) (services.EurekaInstanceInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	instance, ok := s.apps[strings.ToUpper(app)][instanceID]
+	if !ok {
+		return services.EurekaInstanceInfo{}, false
+	}
+	return *instance, true
+}
+
+// Heartbeats returns the number of heartbeats the server accepted from an instance.
+func (s *Server) Heartbeats(instanceID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.heartbeats[instanceID]
+}
+
+func (s *Server) put(instance *services.EurekaInstanceInfo) {
+	app := strings.ToUpper(instance.App)
+	if s.apps[app] == nil {
+		s.apps[app] = make(map[string]*services.EurekaInstanceInfo)
+	}
+	s.apps[app][instance.InstanceID] = instance
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/eureka"), "/"), "/")
+	if path[0] != "apps" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch {
+	case len(path) == 1 && r.Method == http.MethodGet:
+		s.writeApps(w)
+	case len(path) == 2 && r.Method == http.MethodPost:
+		var payload services.EurekaRegistrationPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.put(&payload.Instance)
+		w.WriteHeader(http.StatusNoContent)
+	case len(path) >= 3:
+		instance, ok := s.apps[strings.ToUpper(path[1])][path[2]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case len(path) == 3 && r.Method == http.MethodPut:
+			s.heartbeats[instance.InstanceID]++
+		case len(path) == 3 && r.Method == http.MethodDelete:
+			delete(s.apps[strings.ToUpper(path[1])], path[2])
+		case len(path) == 4 && path[3] == "status" && r.Method == http.MethodPut:
+			instance.Status = r.URL.Query().Get("value")
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+type application struct {
+	Name     string                        `json:"name"`
+	Instance []services.EurekaInstanceInfo `json:"instance"`
+}
+
+func (s *Server) writeApps(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.apps))
+	for name := range s.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	apps := make([]application, 0, len(names))
+	for _, name := range names {
+		app := application{Name: name}
+		for _, instance := range s.apps[name] {
+			app.Instance = append(app.Instance, *instance)
+		}
+		if len(app.Instance) > 0 {
+			apps = append(apps, app)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"applications": map[string]interface{}{"application": apps},
+	})
+}
@@ -8,18 +8,28 @@ import (
 
 var jwtSecret = []byte("your-secret-key") // Replace with a secure key in production
 
+const (
+	mfaChallengePurpose = "mfa_challenge"
+	mfaChallengeTTL     = 5 * time.Minute
+)
+
 // Claims defines the custom claims for the JWT
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID       string `json:"user_id"`
+	Role         string `json:"role"`
+	TokenVersion int    `json:"token_version"`
+	MFA          bool   `json:"mfa,omitempty"`     // True when the session passed a second factor
+	Purpose      string `json:"purpose,omitempty"` // Set on restricted tokens that are not session tokens
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT creates a new JWT token with user ID and role
-func GenerateJWT(userID, role string) (string, error) {
+// GenerateJWT creates a new JWT token with user ID, role and the user's current token version
+func GenerateJWT(userID, role string, tokenVersion int, mfa bool) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:       userID,
+		Role:         role,
+		TokenVersion: tokenVersion,
+		MFA:          mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -30,8 +40,48 @@ func GenerateJWT(userID, role string) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
-// ValidateJWT parses and validates a JWT token, returning the claims
+// GenerateMFAChallengeJWT creates a short-lived token proving the password step of a
+// two-step login; it can only be exchanged for a session token at the MFA endpoint
+func GenerateMFAChallengeJWT(userID string, tokenVersion int) (string, error) {
+	claims := Claims{
+		UserID:       userID,
+		TokenVersion: tokenVersion,
+		Purpose:      mfaChallengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateJWT parses and validates a session JWT token, returning the claims
 func ValidateJWT(tokenString string) (*Claims, error) {
+	claims, err := parseJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// ValidateMFAChallengeJWT parses a token issued by GenerateMFAChallengeJWT
+func ValidateMFAChallengeJWT(tokenString string) (*Claims, error) {
+	claims, err := parseJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != mfaChallengePurpose {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+func parseJWT(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		// Ensure the signing method is HMAC
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{name: "direct client", peer: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "headers from an untrusted peer", peer: "203.0.113.7:5000", forwarded: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "through the gateway", peer: "10.1.2.3:5000", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed entries left of the client", peer: "10.1.2.3:5000", forwarded: []string{"1.1.1.1, 2.2.2.2, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of trusted proxies", peer: "10.1.2.3:5000", forwarded: []string{"1.1.1.1, 198.51.100.1, 192.168.1.1", "10.9.9.9"}, want: "198.51.100.1"},
+		{name: "only trusted hops", peer: "10.1.2.3:5000", forwarded: []string{"10.0.0.1, 10.0.0.2"}, want: "10.0.0.1"},
+		{name: "malformed hop", peer: "10.1.2.3:5000", forwarded: []string{"198.51.100.1, nonsense"}, want: "10.1.2.3"},
+		{name: "real IP from the gateway", peer: "10.1.2.3:5000", realIP: "198.51.100.2", want: "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/users/login", nil)
+			r.RemoteAddr = tt.peer
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := proxies.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0"} {
+		if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded, want an error", entry)
+		}
+	}
+	if _, err := ParseTrustedProxies([]string{"::1", "fd00::/8"}); err != nil {
+		t.Errorf("ParseTrustedProxies(IPv6) = %v", err)
+	}
+}